@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz/auth"
+	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/session"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// Record is a single JSON line of the audit log
+type Record struct {
+	Time       time.Time      `json:"time"`
+	Session    string         `json:"session,omitempty"`
+	Principal  string         `json:"principal"`
+	Groups     []string       `json:"groups,omitempty"`
+	Tool       string         `json:"tool"`
+	Mutating   bool           `json:"mutating"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	Context    string         `json:"context,omitempty"`
+	Outcome    string         `json:"outcome"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"durationMs"`
+}
+
+// Logger writes audit records as JSON lines
+type Logger struct {
+	mu         sync.Mutex
+	w          io.Writer
+	auditReads bool
+}
+
+// logger is the active audit logger, nil when auditing is disabled
+var logger atomic.Pointer[Logger]
+
+// NewLogger creates an audit logger writing to w. Calls of read-only tools are
+// only recorded when auditReads is set.
+func NewLogger(w io.Writer, auditReads bool) *Logger {
+	return &Logger{w: w, auditReads: auditReads}
+}
+
+// NewRotatingFile opens an audit log file that is rotated once it reaches maxSizeMB,
+// keeping at most maxBackups old files
+func NewRotatingFile(path string, maxSizeMB, maxBackups int) io.WriteCloser {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSizeMB,
+		MaxBackups: maxBackups,
+		LocalTime:  true,
+	}
+}
+
+// SetLogger sets the audit logger used by wrapped tool handlers, nil disables auditing
+func SetLogger(l *Logger) {
+	logger.Store(l)
+}
+
+// Write appends a record to the audit log
+func (l *Logger) Write(record *Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(line)
+	return err
+}
+
+// Wrap records every call of the named tool in the audit log
+func Wrap(tool string, mutating bool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		l := logger.Load()
+		if l == nil || (!mutating && !l.auditReads) {
+			return next(ctx, req)
+		}
+
+		record := newRecord(ctx, tool, mutating, req)
+		start := time.Now()
+
+		result, err := next(ctx, req)
+
+		record.DurationMs = time.Since(start).Milliseconds()
+		record.Outcome = OutcomeSuccess
+		if err != nil {
+			record.Outcome = OutcomeError
+			record.Error = err.Error()
+		} else if result != nil && result.IsError {
+			record.Outcome = OutcomeError
+		}
+		if writeErr := l.Write(record); writeErr != nil {
+			// Never fail the call because of the audit log, but make the gap visible
+			slog.Error("Failed to write audit record", "tool", tool, "error", writeErr)
+		}
+		return result, err
+	}
+}
+
+// newRecord fills the caller and target fields of the record of a tool call
+func newRecord(ctx context.Context, tool string, mutating bool, req *protocol.CallToolRequest) *Record {
+	record := &Record{
+		Time:      time.Now(),
+		Session:   session.IDFromContext(ctx),
+		Principal: auth.PrincipalName(ctx),
+		Tool:      tool,
+		Mutating:  mutating,
+		Arguments: redactArguments(req.RawArguments),
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		record.Groups = principal.Groups
+	}
+	if contextName, err := kubeclient.ResolveContext(ctx); err == nil {
+		record.Context = contextName
+	}
+	return record
+}
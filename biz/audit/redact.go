@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces the value of a secret argument
+const redacted = "[REDACTED]"
+
+// secretKeyParts are argument name fragments whose values are never written to the audit log
+var secretKeyParts = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "credential", "authorization"}
+
+// inlineSecret matches secrets passed inline in strings, e.g. "--password=foo" or "TOKEN=bar"
+var inlineSecret = regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[_-]?key)[^=:\s]*[=:])\S+`)
+
+// redactArguments decodes the raw tool arguments and masks secret values
+func redactArguments(raw json.RawMessage) map[string]any {
+	if len(raw) == 0 {
+		return nil
+	}
+	var args map[string]any
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return map[string]any{"_unparsed": redacted}
+	}
+	for key, value := range args {
+		args[key] = redactValue(key, value)
+	}
+	return args
+}
+
+// redactValue masks the value of a secret key and inline secrets in nested values
+func redactValue(key string, value any) any {
+	if isSecretKey(key) {
+		return redacted
+	}
+	switch v := value.(type) {
+	case string:
+		return inlineSecret.ReplaceAllString(v, "${1}"+redacted)
+	case []any:
+		for i := range v {
+			v[i] = redactValue("", v[i])
+		}
+		return v
+	case map[string]any:
+		for k := range v {
+			v[k] = redactValue(k, v[k])
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// isSecretKey reports whether an argument name looks like it holds a secret
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// APIKeysEnv is the environment variable holding API keys, entries separated by ';'
+const APIKeysEnv = "MCP_API_KEYS"
+
+// apiKey binds a static key to the principal it authenticates
+type apiKey struct {
+	key       []byte
+	principal *Principal
+}
+
+// APIKeyAuthenticator authenticates HTTP requests with static API keys
+type APIKeyAuthenticator struct {
+	mu   sync.RWMutex
+	keys []apiKey
+}
+
+// NewAPIKeyAuthenticator loads API keys from the given file (if any), the given entries
+// and the MCP_API_KEYS environment variable. Each entry has the form
+// "principal:key[:group1,group2]". A nil authenticator is returned when no key is configured.
+func NewAPIKeyAuthenticator(path string, entries []string) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{}
+	if err := a.load(path, entries); err != nil {
+		return nil, err
+	}
+	if len(a.keys) == 0 {
+		return nil, nil
+	}
+	return a, nil
+}
+
+// Reload replaces the keys of the authenticator by the ones loaded from the given file,
+// entries and environment. The current keys are kept when loading fails or yields no key.
+func (a *APIKeyAuthenticator) Reload(path string, entries []string) error {
+	loaded := &APIKeyAuthenticator{}
+	if err := loaded.load(path, entries); err != nil {
+		return err
+	}
+	if len(loaded.keys) == 0 {
+		return fmt.Errorf("no API key configured, keeping the current keys")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.keys = loaded.keys
+	return nil
+}
+
+// load adds the keys of the given file, entries and environment to the authenticator
+func (a *APIKeyAuthenticator) load(path string, entries []string) error {
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("unable to open API keys file: %v", err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			if err := a.addEntry(scanner.Text()); err != nil {
+				return fmt.Errorf("%s:%d: %v", path, lineNo, err)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("unable to read API keys file: %v", err)
+		}
+	}
+
+	for i, entry := range entries {
+		if err := a.addEntry(entry); err != nil {
+			return fmt.Errorf("API key entry %d: %v", i+1, err)
+		}
+	}
+
+	for _, entry := range strings.Split(os.Getenv(APIKeysEnv), ";") {
+		if err := a.addEntry(entry); err != nil {
+			return fmt.Errorf("%s: %v", APIKeysEnv, err)
+		}
+	}
+	return nil
+}
+
+// AddKey registers a key authenticating the given principal
+func (a *APIKeyAuthenticator) AddKey(key string, principal *Principal) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.keys = append(a.keys, apiKey{key: []byte(key), principal: principal})
+}
+
+// addEntry parses a "principal:key[:group1,group2]" entry, skipping blanks and comments
+func (a *APIKeyAuthenticator) addEntry(entry string) error {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || strings.HasPrefix(entry, "#") {
+		return nil
+	}
+
+	parts := strings.SplitN(entry, ":", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid API key entry, expected principal:key[:group1,group2]")
+	}
+
+	principal := &Principal{Name: parts[0]}
+	if len(parts) == 3 && parts[2] != "" {
+		principal.Groups = strings.Split(parts[2], ",")
+	}
+	a.AddKey(parts[1], principal)
+	return nil
+}
+
+// Authenticate returns the principal owning the API key presented by the request
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, bool) {
+	presented := requestKey(r)
+	if presented == "" {
+		return nil, false
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	// Compare against every key so the lookup time does not depend on which key matched
+	var principal *Principal
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(k.key, []byte(presented)) == 1 {
+			principal = k.principal
+		}
+	}
+	return principal, principal != nil
+}
+
+// requestKey extracts the API key from the Authorization or X-API-Key header
+func requestKey(r *http.Request) string {
+	if authz := r.Header.Get("Authorization"); authz != "" {
+		if key, ok := strings.CutPrefix(authz, "Bearer "); ok {
+			return strings.TrimSpace(key)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
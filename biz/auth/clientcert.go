@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// ClientCertAuthenticator identifies callers by their verified TLS client certificate.
+// Like Kubernetes x509 authentication, the subject common name is the principal name
+// and the organizations are its groups.
+type ClientCertAuthenticator struct{}
+
+// Authenticate returns the principal of the verified client certificate of the request
+func (ClientCertAuthenticator) Authenticate(r *http.Request) (*Principal, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	subject := r.TLS.VerifiedChains[0][0].Subject
+	name := subject.CommonName
+	if name == "" {
+		name = subject.String()
+	}
+	return &Principal{Name: name, Groups: subject.Organization}, true
+}
+
+// NewServerTLSConfig builds the TLS configuration of the HTTPS server. When clientCAFile
+// is set, client certificates are verified against its CAs if presented. They are not
+// required during the handshake, so that probes and metrics scrapers can connect;
+// the Middleware of the MCP endpoints refuses callers without a certificate or API key.
+func NewServerTLSConfig(clientCAFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if clientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	caPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client CA file: %v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no valid certificate found in client CA file %s", clientCAFile)
+	}
+
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	return tlsConfig, nil
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"net/http"
+)
+
+// Authenticator identifies the principal behind an HTTP request
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, bool)
+}
+
+// Middleware rejects requests that none of the authenticators accept and stores
+// the principal of the first one that does in the request context
+func Middleware(next http.Handler, authenticators ...Authenticator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, authenticator := range authenticators {
+			if principal, ok := authenticator.Authenticate(r); ok {
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+				return
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-k8s"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
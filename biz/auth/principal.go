@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+)
+
+// Principal is the authenticated caller of the MCP server
+type Principal struct {
+	Name   string
+	Groups []string
+}
+
+// principalKey is the context key under which the Principal is stored
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal carried by ctx, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// PrincipalName returns the name of the principal carried by ctx, or "anonymous"
+func PrincipalName(ctx context.Context) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.Name
+	}
+	return "anonymous"
+}
@@ -1,42 +1,98 @@
 package clientset
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
-	"sync/atomic"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz/logging"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/session"
 
 	kruiseclientset "github.com/openkruise/kruise-api/client/clientset/versioned"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
-var (
+// sessionState holds the cluster selection of a single MCP session
+type sessionState struct {
+	mu                   sync.RWMutex
 	currentContext       string
-	customKubeconfigPath string 
-	clientsetPointer     atomic.Pointer[kubernetes.Clientset]
-	kruiseClientPointer  atomic.Pointer[kruiseclientset.Clientset]
-)
+	customKubeconfigPath string
+}
 
-// ValidateAndFixKubeconfig validates and fixes invalid kubeconfig files
-func ValidateAndFixKubeconfig(path string) error {
-	fmt.Printf("Debug - Validating kubeconfig file: %s\n", path)
+// contextNameKey is the context key of a per-call cluster context override
+type contextNameKey struct{}
+
+// sessions maps MCP session IDs to their sessionState
+var sessions sync.Map
 
-	// Check if file exists
-	_, err := os.Stat(path)
-	if os.IsNotExist(err) {
-		return fmt.Errorf("kubeconfig file does not exist: %s", path)
+// defaultKubeconfig holds the kubeconfig path and context of sessions that have not selected their own
+var defaultKubeconfig = struct {
+	sync.RWMutex
+	path        string
+	contextName string
+}{}
+
+func init() {
+	// Drop the cluster selection of a session once it has ended
+	session.OnClose(func(id string) {
+		sessions.Delete(id)
+	})
+}
+
+// getSessionState gets or creates the state of the session carried by ctx
+func getSessionState(ctx context.Context) *sessionState {
+	id := session.IDFromContext(ctx)
+	if s, ok := sessions.Load(id); ok {
+		return s.(*sessionState)
 	}
+	s, _ := sessions.LoadOrStore(id, &sessionState{})
+	return s.(*sessionState)
+}
 
-	// Try to read file content
-	_, err = os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("unable to read kubeconfig file: %v", err)
+// selection returns the kubeconfig path and context currently selected by the session
+func (s *sessionState) selection() (string, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.customKubeconfigPath, s.currentContext
+}
+
+// ValidateAndFixKubeconfig validates and fixes invalid kubeconfig files. The path may list
+// several files merged like $KUBECONFIG: each file must parse, and the merged configuration
+// must contain at least one cluster, context, and user, as a file may hold only some of them.
+func ValidateAndFixKubeconfig(path string) error {
+	slog.Debug("Validating kubeconfig file", "path", path)
+
+	for _, file := range filepath.SplitList(path) {
+		// Check if file exists
+		_, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("kubeconfig file does not exist: %s", file)
+		}
+
+		// Try to read file content
+		_, err = os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("unable to read kubeconfig file: %v", err)
+		}
+
+		// Try to load the file
+		if _, err := clientcmd.LoadFromFile(file); err != nil {
+			return fmt.Errorf("invalid kubeconfig file format(%s): %v", file, err)
+		}
 	}
 
-	// Try to load the file
-	config, err := clientcmd.LoadFromFile(path)
+	// Load the merged configuration
+	config, err := LoadingRules(path).Load()
 	if err != nil {
 		return fmt.Errorf("invalid kubeconfig file format: %v", err)
 	}
@@ -46,8 +102,8 @@ func ValidateAndFixKubeconfig(path string) error {
 		return fmt.Errorf("invalid kubeconfig configuration, must contain at least one cluster, context, and user")
 	}
 
-	fmt.Printf("Debug - Kubeconfig file validation successful, contains %d clusters, %d contexts, %d users\n",
-		len(config.Clusters), len(config.Contexts), len(config.AuthInfos))
+	slog.Debug("Kubeconfig file validation successful", "path", path,
+		"clusters", len(config.Clusters), "contexts", len(config.Contexts), "users", len(config.AuthInfos))
 
 	return nil
 }
@@ -58,226 +114,266 @@ func isValidConfig(config *clientcmdapi.Config) bool {
 	return len(config.Clusters) > 0 && len(config.Contexts) > 0 && len(config.AuthInfos) > 0
 }
 
-// GetCurrentContext gets the current context
-func GetCurrentContext() (string, error) {
+// GetCurrentContext gets the current context of the calling session
+func GetCurrentContext(ctx context.Context) (string, error) {
+	s := getSessionState(ctx)
+
 	// If current context is already set, return it directly
-	if currentContext != "" {
-		return currentContext, nil
+	if _, contextName := s.selection(); contextName != "" {
+		return contextName, nil
+	}
+
+	// Otherwise, use the configured default context unless the session brought its own kubeconfig
+	if customKubeconfigPath, _ := s.selection(); customKubeconfigPath == "" {
+		if _, contextName := DefaultKubeconfig(); contextName != "" {
+			return contextName, nil
+		}
 	}
 
 	// Otherwise, get from kubeconfig file
-	config, err := GetKubeConfig()
+	config, err := GetKubeConfig(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	// Output debug information
-	fmt.Printf("Debug - CurrentContext from kubeconfig: %s\n", config.CurrentContext)
-	fmt.Printf("Debug - Number of available contexts: %d\n", len(config.Contexts))
+	logger := logging.FromContext(ctx)
+	logger.Debug("Loaded current context from kubeconfig",
+		"currentContext", config.CurrentContext, "availableContexts", len(config.Contexts))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another call may have resolved the context meanwhile
+	if s.currentContext != "" {
+		return s.currentContext, nil
+	}
 
 	// Save current context
-	currentContext = config.CurrentContext
+	s.currentContext = config.CurrentContext
 
 	// If current context is empty but there are available contexts, try to use the first available context
-	if currentContext == "" && len(config.Contexts) > 0 {
+	if s.currentContext == "" && len(config.Contexts) > 0 {
 		// Get the name of the first available context
 		for name := range config.Contexts {
-			fmt.Printf("Debug - Found available context: %s, setting as current context\n", name)
-			currentContext = name
+			logger.Debug("Kubeconfig has no current context, using first available context", "currentContext", name)
+			s.currentContext = name
 			break
 		}
 	}
 
-	return currentContext, nil
+	return s.currentContext, nil
 }
 
-// GetKubeConfig gets kubeconfig configuration
-func GetKubeConfig() (*clientcmdapi.Config, error) {
-	var configAccess clientcmd.ConfigAccess
+// GetKubeConfig gets kubeconfig configuration of the calling session
+func GetKubeConfig(ctx context.Context) (*clientcmdapi.Config, error) {
+	logger := logging.FromContext(ctx)
+
+	customKubeconfigPath, _ := getSessionState(ctx).selection()
+	if usesInCluster(customKubeconfigPath) {
+		// Use the mounted ServiceAccount token
+		logger.Debug("Using in-cluster configuration")
+		return inClusterKubeConfig()
+	}
 
 	if customKubeconfigPath != "" {
-		// Check if custom kubeconfig file exists
-		_, err := clientcmd.LoadFromFile(customKubeconfigPath)
-		if err != nil {
-			fmt.Printf("Debug - Unable to load custom kubeconfig file: %v\n", err)
-			return nil, fmt.Errorf("unable to load custom kubeconfig file(%s): %v", customKubeconfigPath, err)
+		// Check if the custom kubeconfig files exist
+		for _, file := range filepath.SplitList(customKubeconfigPath) {
+			if _, err := clientcmd.LoadFromFile(file); err != nil {
+				logger.Debug("Unable to load custom kubeconfig file", "path", file, "error", err)
+				return nil, fmt.Errorf("unable to load custom kubeconfig file(%s): %v", file, err)
+			}
 		}
-		fmt.Printf("Debug - Using custom kubeconfig path: %s\n", customKubeconfigPath)
-		// Use custom kubeconfig path
-		configAccess = &clientcmd.ClientConfigLoadingRules{ExplicitPath: customKubeconfigPath}
+		logger.Debug("Using custom kubeconfig path", "path", customKubeconfigPath)
 	} else {
 		// Use default kubeconfig path
-		fmt.Println("Debug - Using default kubeconfig path")
-		configAccess = clientcmd.NewDefaultClientConfigLoadingRules()
+		logger.Debug("Using default kubeconfig path")
 	}
+	configAccess := LoadingRules(customKubeconfigPath)
 
 	config, err := configAccess.GetStartingConfig()
 	if err != nil {
-		fmt.Printf("Debug - Failed to get kubeconfig configuration: %v\n", err)
+		logger.Debug("Failed to get kubeconfig configuration", "error", err)
 		return nil, fmt.Errorf("error loading kubeconfig: %v", err)
 	}
 	return config, nil
 }
 
-// GetKubeClient gets Kubernetes client
-func GetKubeClient() (kubernetes.Interface, error) {
-	// First try to load existing clientset from atomic pointer
-	if cs := clientsetPointer.Load(); cs != nil {
-		return cs, nil
-	}
-
-	// If no cached clientset, create a new one
-	contextName, err := GetCurrentContext()
-	if err != nil {
-		return nil, err
-	}
+// SetDefaultKubeconfig sets the kubeconfig path and context used by sessions that have not
+// selected their own. Empty values fall back to the default loading rules and the
+// kubeconfig's current-context.
+func SetDefaultKubeconfig(path, contextName string) {
+	defaultKubeconfig.Lock()
+	defer defaultKubeconfig.Unlock()
+	defaultKubeconfig.path = path
+	defaultKubeconfig.contextName = contextName
+}
 
-	// Create client config loader
-	var configLoader clientcmd.ClientConfig
+// DefaultKubeconfig returns the configured default kubeconfig path and context
+func DefaultKubeconfig() (string, string) {
+	defaultKubeconfig.RLock()
+	defer defaultKubeconfig.RUnlock()
+	return defaultKubeconfig.path, defaultKubeconfig.contextName
+}
 
-	if customKubeconfigPath != "" {
-		// Use custom kubeconfig path
-		configLoader = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			&clientcmd.ClientConfigLoadingRules{ExplicitPath: customKubeconfigPath},
-			&clientcmd.ConfigOverrides{
-				CurrentContext: contextName,
-			})
-	} else {
-		// Use default kubeconfig path
-		configLoader = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			clientcmd.NewDefaultClientConfigLoadingRules(),
-			&clientcmd.ConfigOverrides{
-				CurrentContext: contextName,
-			})
+// LoadingRules returns the loading rules of a kubeconfig path, an empty path standing
+// for the configured default kubeconfig or else the default loading rules. A list of
+// files joined like $KUBECONFIG is merged, the first file defining a name winning.
+func LoadingRules(kubeconfigPath string) *clientcmd.ClientConfigLoadingRules {
+	if kubeconfigPath == "" {
+		kubeconfigPath, _ = DefaultKubeconfig()
 	}
-
-	config, err := configLoader.ClientConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load kubeconfig for context %s: %v", contextName, err)
+	if files := filepath.SplitList(kubeconfigPath); len(files) > 1 {
+		return &clientcmd.ClientConfigLoadingRules{Precedence: files}
 	}
-
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kubernetes client for context %s: %v", contextName, err)
+	if kubeconfigPath != "" {
+		return &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath}
 	}
+	return clientcmd.NewDefaultClientConfigLoadingRules()
+}
 
-	// Store newly created clientset in atomic pointer
-	clientsetPointer.Store(clientset)
-
-	return clientset, nil
+// WithContextName returns a copy of ctx that targets the named cluster context
+// instead of the session's current context
+func WithContextName(ctx context.Context, contextName string) context.Context {
+	return context.WithValue(ctx, contextNameKey{}, contextName)
 }
 
-// getKruiseClient gets OpenKruise client
-func getKruiseClient() (kruiseclientset.Interface, error) {
-	// If context name is not specified, use current context
-	contextName, err := GetCurrentContext()
-	if err != nil {
-		return nil, err
+// ResolveContext returns the cluster context a call targets: the per-call
+// override if any, otherwise the session's current context
+func ResolveContext(ctx context.Context) (string, error) {
+	if contextName, _ := ctx.Value(contextNameKey{}).(string); contextName != "" {
+		return contextName, nil
 	}
+	return GetCurrentContext(ctx)
+}
 
-	if kc := kruiseClientPointer.Load(); kc != nil {
-		return kc, nil
+// resolvePoolKey returns the pool key of the cluster a call targets and the user it impersonates
+func resolvePoolKey(ctx context.Context) (poolKey, error) {
+	contextName, err := ResolveContext(ctx)
+	if err != nil {
+		return poolKey{}, err
 	}
-
-	var configLoader clientcmd.ClientConfig
-
-	if customKubeconfigPath != "" {
-		// Use custom kubeconfig path
-		configLoader = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			&clientcmd.ClientConfigLoadingRules{ExplicitPath: customKubeconfigPath},
-			&clientcmd.ConfigOverrides{
-				CurrentContext: contextName,
-			})
-	} else {
-		// Use default kubeconfig path
-		configLoader = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			clientcmd.NewDefaultClientConfigLoadingRules(),
-			&clientcmd.ConfigOverrides{
-				CurrentContext: contextName,
-			})
+	user, groups, err := impersonatedUser(ctx)
+	if err != nil {
+		return poolKey{}, err
 	}
+	return poolKey{
+		kubeconfigPath:    GetCustomKubeconfigPath(ctx),
+		contextName:       contextName,
+		impersonateUser:   user,
+		impersonateGroups: strings.Join(groups, "\n"),
+	}, nil
+}
 
-	config, err := configLoader.ClientConfig()
+// GetKubeClient gets Kubernetes client of the cluster the call targets
+func GetKubeClient(ctx context.Context) (kubernetes.Interface, error) {
+	key, err := resolvePoolKey(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load kubeconfig for context %s: %v", contextName, err)
+		return nil, err
 	}
+	return pool.kubeClient(key)
+}
 
-	// Create OpenKruise client
-	kruiseClient, err := kruiseclientset.NewForConfig(config)
+// GetKruiseClient gets OpenKruise client of the cluster the call targets
+func GetKruiseClient(ctx context.Context) (kruiseclientset.Interface, error) {
+	key, err := resolvePoolKey(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create OpenKruise client for context %s: %v", contextName, err)
+		return nil, err
 	}
+	return pool.kruiseClient(key)
+}
 
-	// Store newly created kruise client in atomic pointer
-	kruiseClientPointer.Store(kruiseClient)
-
-	return kruiseClient, nil
+// UsesInCluster reports whether the calling session reaches its cluster with the
+// mounted ServiceAccount token rather than a kubeconfig file
+func UsesInCluster(ctx context.Context) bool {
+	return usesInCluster(GetCustomKubeconfigPath(ctx))
 }
 
-// GetKruiseClient exported OpenKruise client getter function for use by sub-packages
-func GetKruiseClient() (kruiseclientset.Interface, error) {
-	return getKruiseClient()
+// InvalidateClients drops all cached clients created from the given kubeconfig path,
+// an empty path standing for the default kubeconfig
+func InvalidateClients(kubeconfigPath string) {
+	pool.invalidate(kubeconfigPath)
+	slog.Debug("Client cache cleared", "kubeconfig", kubeconfigPath)
 }
 
-// ClearClientCache clears client cache
-func ClearClientCache() {
-	// Clear clientset and kruiseClient cache
-	clientsetPointer.Store(nil)
-	kruiseClientPointer.Store(nil)
-	fmt.Println("Debug - Client cache cleared")
+// CachedClients returns the number of pooled cluster clients per context name,
+// a context cached for several kubeconfig files or impersonated users being
+// counted once per file and user
+func CachedClients() map[string]int {
+	return pool.cachedClients()
 }
 
-// SetCustomKubeconfigPath sets custom kubeconfig path
-func SetCustomKubeconfigPath(path string) {
-	customKubeconfigPath = path
+// SetCustomKubeconfigPath sets custom kubeconfig path of the calling session,
+// a single file or several files joined with JoinKubeconfigPaths
+func SetCustomKubeconfigPath(ctx context.Context, path string) {
+	s := getSessionState(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.customKubeconfigPath = path
 }
 
-// GetCustomKubeconfigPath gets custom kubeconfig path
-func GetCustomKubeconfigPath() string {
-	return customKubeconfigPath
+// GetCustomKubeconfigPath gets custom kubeconfig path of the calling session
+func GetCustomKubeconfigPath(ctx context.Context) string {
+	path, _ := getSessionState(ctx).selection()
+	return path
 }
 
-// ResetCurrentContext resets current context
-func ResetCurrentContext() {
-	currentContext = ""
+// ResetCurrentContext resets current context of the calling session
+func ResetCurrentContext(ctx context.Context) {
+	SetCurrentContext(ctx, "")
 }
 
-// SetCurrentContext sets current context
-func SetCurrentContext(ctx string) {
-	currentContext = ctx
+// SetCurrentContext sets current context of the calling session
+func SetCurrentContext(ctx context.Context, contextName string) {
+	s := getSessionState(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentContext = contextName
 }
 
-// GetRESTConfig gets configuration for creating REST client
-func GetRESTConfig() (*rest.Config, error) {
-	// Get current context
-	contextName, err := GetCurrentContext()
+// DefaultServerVersion asks the API server of the configured default kubeconfig and context
+// for its version, giving up after timeout, and returns it with the context name. Session
+// state is ignored, so that no caller can point it at another cluster.
+func DefaultServerVersion(timeout time.Duration) (string, *version.Info, error) {
+	contextName, err := DefaultContext()
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
+	config, err := pool.restConfig(poolKey{contextName: contextName})
+	if err != nil {
+		return contextName, nil, err
+	}
+	config.Timeout = timeout
 
-	var configLoader clientcmd.ClientConfig
-
-	if customKubeconfigPath != "" {
-		// Use custom kubeconfig path
-		configLoader = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			&clientcmd.ClientConfigLoadingRules{ExplicitPath: customKubeconfigPath},
-			&clientcmd.ConfigOverrides{
-				CurrentContext: contextName,
-			})
-	} else {
-		// Use default kubeconfig path
-		configLoader = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			clientcmd.NewDefaultClientConfigLoadingRules(),
-			&clientcmd.ConfigOverrides{
-				CurrentContext: contextName,
-			})
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return contextName, nil, fmt.Errorf("failed to create discovery client: %v", err)
 	}
+	info, err := client.ServerVersion()
+	return contextName, info, err
+}
 
-	// Load kubeconfig file
-	config, err := configLoader.ClientConfig()
+// DefaultContext returns the context used by sessions that have selected neither a
+// kubeconfig nor a context: the configured default context, the in-cluster context,
+// or else the current-context of the default kubeconfig
+func DefaultContext() (string, error) {
+	if _, contextName := DefaultKubeconfig(); contextName != "" {
+		return contextName, nil
+	}
+	if usesInCluster("") {
+		return InClusterContextName, nil
+	}
+	config, err := LoadingRules("").Load()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load kubeconfig for context %s: %v", contextName, err)
+		return "", fmt.Errorf("error loading kubeconfig: %v", err)
 	}
+	return config.CurrentContext, nil
+}
 
-	return config, nil
+// GetRESTConfig gets configuration for creating REST client of the cluster the call targets
+func GetRESTConfig(ctx context.Context) (*rest.Config, error) {
+	key, err := resolvePoolKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pool.restConfig(key)
 }
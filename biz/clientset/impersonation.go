@@ -0,0 +1,95 @@
+package clientset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz/auth"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/policy"
+
+	"k8s.io/client-go/rest"
+)
+
+// anyPrincipal is the impersonation mapping of principals without their own
+const anyPrincipal = "*"
+
+// ImpersonationConfig maps MCP principals to the Kubernetes user and groups their
+// requests impersonate, so that the cluster's RBAC decides what each caller may do
+type ImpersonationConfig struct {
+	Enabled    bool                        `json:"enabled"`
+	Principals map[string]ImpersonatedUser `json:"principals"`
+}
+
+// ImpersonatedUser is the Kubernetes identity of a principal. An empty User stands
+// for the principal's own name.
+type ImpersonatedUser struct {
+	User   string   `json:"user"`
+	Groups []string `json:"groups"`
+}
+
+var impersonation = struct {
+	sync.RWMutex
+	config ImpersonationConfig
+}{}
+
+// Validate checks that impersonation, when enabled, maps at least one principal
+func (c ImpersonationConfig) Validate() error {
+	if c.Enabled && len(c.Principals) == 0 {
+		return errors.New("enabled without any principal mapped")
+	}
+	return nil
+}
+
+// SetImpersonation sets how the Kubernetes requests of authenticated callers are
+// impersonated. Requests made without a caller, such as readiness checks, keep
+// the server's own credentials.
+func SetImpersonation(config ImpersonationConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	impersonation.Lock()
+	defer impersonation.Unlock()
+	impersonation.config = config
+	return nil
+}
+
+// impersonatedUser returns the Kubernetes user and groups the caller carried by ctx
+// impersonates, an empty user meaning the server's own credentials
+func impersonatedUser(ctx context.Context) (string, []string, error) {
+	impersonation.RLock()
+	defer impersonation.RUnlock()
+	if !impersonation.config.Enabled {
+		return "", nil, nil
+	}
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return "", nil, nil
+	}
+
+	mapped, ok := impersonation.config.Principals[principal.Name]
+	if !ok {
+		mapped, ok = impersonation.config.Principals[anyPrincipal]
+	}
+	if !ok {
+		return "", nil, fmt.Errorf("%w: principal %q has no Kubernetes user to impersonate", policy.ErrPolicyViolation, principal.Name)
+	}
+	if mapped.User == "" {
+		mapped.User = principal.Name
+	}
+	return mapped.User, mapped.Groups, nil
+}
+
+// impersonate makes the REST config act as the user and groups of the pool key
+func impersonate(config *rest.Config, key poolKey) {
+	if key.impersonateUser == "" {
+		return
+	}
+	config.Impersonate = rest.ImpersonationConfig{UserName: key.impersonateUser}
+	if key.impersonateGroups != "" {
+		config.Impersonate.Groups = strings.Split(key.impersonateGroups, "\n")
+	}
+}
@@ -0,0 +1,95 @@
+package clientset
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// InClusterContextName is the name of the synthetic context used in in-cluster mode
+	InClusterContextName = "in-cluster"
+
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+var inClusterMode atomic.Bool
+
+// SetInClusterMode enables authentication with the mounted ServiceAccount token
+// for sessions that have not set a custom kubeconfig path
+func SetInClusterMode(enabled bool) {
+	inClusterMode.Store(enabled)
+}
+
+// InClusterMode reports whether in-cluster mode is enabled
+func InClusterMode() bool {
+	return inClusterMode.Load()
+}
+
+// DetectInCluster reports whether the process runs in a Pod with a mounted
+// ServiceAccount token and no kubeconfig file is available
+func DetectInCluster() bool {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return false
+	}
+	if _, err := os.Stat(serviceAccountDir + "/token"); err != nil {
+		return false
+	}
+	for _, path := range clientcmd.NewDefaultClientConfigLoadingRules().GetLoadingPrecedence() {
+		if _, err := os.Stat(path); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// usesInCluster reports whether a cluster is reached with the ServiceAccount token
+func usesInCluster(kubeconfigPath string) bool {
+	return kubeconfigPath == "" && InClusterMode()
+}
+
+// inClusterKubeConfig builds a kubeconfig holding the single synthetic in-cluster context
+func inClusterKubeConfig() (*clientcmdapi.Config, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading in-cluster configuration: %v", err)
+	}
+
+	namespace := "default"
+	if data, err := os.ReadFile(serviceAccountDir + "/namespace"); err == nil {
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters[InClusterContextName] = &clientcmdapi.Cluster{
+		Server:               restConfig.Host,
+		CertificateAuthority: restConfig.TLSClientConfig.CAFile,
+	}
+	config.AuthInfos[InClusterContextName] = &clientcmdapi.AuthInfo{
+		TokenFile: restConfig.BearerTokenFile,
+	}
+	config.Contexts[InClusterContextName] = &clientcmdapi.Context{
+		Cluster:   InClusterContextName,
+		AuthInfo:  InClusterContextName,
+		Namespace: namespace,
+	}
+	config.CurrentContext = InClusterContextName
+	return config, nil
+}
+
+// loadInClusterRESTConfig builds the REST config of the synthetic in-cluster context
+func loadInClusterRESTConfig(contextName string) (*rest.Config, error) {
+	if contextName != InClusterContextName {
+		return nil, fmt.Errorf("context %q does not exist, only %q is available in in-cluster mode", contextName, InClusterContextName)
+	}
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading in-cluster configuration: %v", err)
+	}
+	return config, nil
+}
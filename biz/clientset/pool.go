@@ -0,0 +1,254 @@
+package clientset
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz/policy"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/tracing"
+
+	kruiseclientset "github.com/openkruise/kruise-api/client/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// DefaultClientIdleTimeout is how long an unused cluster client stays in the pool
+const DefaultClientIdleTimeout = 30 * time.Minute
+
+// poolKey identifies a cluster by kubeconfig path and context name, and the user its
+// requests impersonate. An empty kubeconfigPath stands for the default loading rules,
+// an empty impersonateUser for the credentials of the kubeconfig.
+type poolKey struct {
+	kubeconfigPath    string
+	contextName       string
+	impersonateUser   string
+	impersonateGroups string
+}
+
+// poolEntry holds the clients created for a single cluster
+type poolEntry struct {
+	restConfig   *rest.Config
+	kubeClient   *kubernetes.Clientset
+	kruiseClient *kruiseclientset.Clientset
+	lastUsed     time.Time
+	// fingerprint captures the kubeconfig entries the clients were built from
+	fingerprint string
+}
+
+// clientPool lazily creates and caches clients per cluster
+type clientPool struct {
+	mu          sync.Mutex
+	entries     map[poolKey]*poolEntry
+	idleTimeout time.Duration
+	qps         float32
+	burst       int
+	janitor     sync.Once
+}
+
+var pool = &clientPool{
+	entries:     make(map[poolKey]*poolEntry),
+	idleTimeout: DefaultClientIdleTimeout,
+}
+
+// SetClientIdleTimeout sets how long unused clients are kept before being evicted
+func SetClientIdleTimeout(d time.Duration) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.idleTimeout = d
+}
+
+// SetClientRateLimits sets the client-side QPS and burst of the Kubernetes clients created
+// from now on. Zero values keep the client-go defaults.
+func SetClientRateLimits(qps float32, burst int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.qps = qps
+	pool.burst = burst
+}
+
+// get returns the pool entry of the cluster, creating its REST config if needed
+func (p *clientPool) get(key poolKey) (*poolEntry, error) {
+	p.janitor.Do(func() {
+		go p.evictIdle()
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry, ok := p.entries[key]; ok {
+		entry.lastUsed = time.Now()
+		return entry, nil
+	}
+
+	config, err := loadRESTConfig(key)
+	if err != nil {
+		return nil, err
+	}
+	if p.qps > 0 {
+		config.QPS = p.qps
+	}
+	if p.burst > 0 {
+		config.Burst = p.burst
+	}
+	impersonate(config, key)
+	// Trace the Kubernetes API requests of all clients built from this config
+	config.Wrap(tracing.WrapTransport)
+	// Refuse requests to namespaces outside the namespace restrictions
+	config.Wrap(policy.WrapTransport)
+
+	entry := &poolEntry{
+		restConfig: config,
+		lastUsed:   time.Now(),
+	}
+	entry.fingerprint, _ = kubeconfigFingerprint(key)
+	p.entries[key] = entry
+	// Drop the clients once their kubeconfig file changes
+	watchKubeconfig(key)
+	return entry, nil
+}
+
+// kubeClient returns the cached Kubernetes client of the cluster
+func (p *clientPool) kubeClient(key poolKey) (*kubernetes.Clientset, error) {
+	entry, err := p.get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry.kubeClient == nil {
+		clientset, err := kubernetes.NewForConfig(entry.restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes client for context %s: %v", key.contextName, err)
+		}
+		entry.kubeClient = clientset
+	}
+	return entry.kubeClient, nil
+}
+
+// kruiseClient returns the cached OpenKruise client of the cluster
+func (p *clientPool) kruiseClient(key poolKey) (*kruiseclientset.Clientset, error) {
+	entry, err := p.get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry.kruiseClient == nil {
+		kruiseClient, err := kruiseclientset.NewForConfig(entry.restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OpenKruise client for context %s: %v", key.contextName, err)
+		}
+		entry.kruiseClient = kruiseClient
+	}
+	return entry.kruiseClient, nil
+}
+
+// restConfig returns a copy of the REST config of the cluster
+func (p *clientPool) restConfig(key poolKey) (*rest.Config, error) {
+	entry, err := p.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return rest.CopyConfig(entry.restConfig), nil
+}
+
+// invalidate drops all clients created from the given kubeconfig path
+func (p *clientPool) invalidate(kubeconfigPath string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key := range p.entries {
+		if key.kubeconfigPath == kubeconfigPath {
+			delete(p.entries, key)
+		}
+	}
+}
+
+// kubeconfigPaths returns the kubeconfig paths of the pooled clients loaded from any of the files
+func (p *clientPool) kubeconfigPaths(files []string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var paths []string
+	for key := range p.entries {
+		if loadsFrom(key, files) && !slices.Contains(paths, key.kubeconfigPath) {
+			paths = append(paths, key.kubeconfigPath)
+		}
+	}
+	return paths
+}
+
+// invalidateChanged drops the clients loaded from any of the kubeconfig paths whose context,
+// cluster or user changed, returning their keys
+func (p *clientPool) invalidateChanged(kubeconfigPaths []string) []poolKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var dropped []poolKey
+	for key, entry := range p.entries {
+		if !slices.Contains(kubeconfigPaths, key.kubeconfigPath) {
+			continue
+		}
+		if fingerprint, err := kubeconfigFingerprint(key); err == nil && fingerprint == entry.fingerprint {
+			continue
+		}
+		delete(p.entries, key)
+		dropped = append(dropped, key)
+	}
+	return dropped
+}
+
+// cachedClients returns the number of cached cluster entries per context name
+func (p *clientPool) cachedClients() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	counts := make(map[string]int)
+	for key := range p.entries {
+		counts[key.contextName]++
+	}
+	return counts
+}
+
+// evictIdle periodically removes clients that have not been used for idleTimeout
+func (p *clientPool) evictIdle() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.mu.Lock()
+		for key, entry := range p.entries {
+			if p.idleTimeout > 0 && time.Since(entry.lastUsed) > p.idleTimeout {
+				delete(p.entries, key)
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// loadRESTConfig builds the REST config of the cluster from its kubeconfig or the in-cluster environment
+func loadRESTConfig(key poolKey) (*rest.Config, error) {
+	if usesInCluster(key.kubeconfigPath) {
+		return loadInClusterRESTConfig(key.contextName)
+	}
+
+	configLoader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		LoadingRules(key.kubeconfigPath),
+		&clientcmd.ConfigOverrides{
+			CurrentContext: key.contextName,
+		})
+
+	// Load kubeconfig file
+	config, err := configLoader.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig for context %s: %v", key.contextName, err)
+	}
+	return config, nil
+}
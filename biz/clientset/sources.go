@@ -0,0 +1,143 @@
+package clientset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// KubeconfigSources tells which file each context of a merged kubeconfig comes from
+type KubeconfigSources struct {
+	// Files are the kubeconfig files in merge order, earlier files winning
+	Files []string
+	// Contexts maps each context name to the file its definition is taken from
+	Contexts map[string]string
+	// Collisions describes the contexts, clusters and users defined in several files
+	Collisions []string
+}
+
+// ExpandKubeconfigPaths resolves kubeconfig paths to the files they name. A directory
+// stands for the files directly inside it, in name order and skipping hidden files,
+// and a leading ~ for the home directory. The files can be passed as one kubeconfig
+// path with JoinKubeconfigPaths.
+func ExpandKubeconfigPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if path == "~" || strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("unable to expand %s: %v", path, err)
+			}
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read kubeconfig path: %v", err)
+		}
+		if !info.IsDir() {
+			files = appendUnique(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read kubeconfig directory: %v", err)
+		}
+		found := false
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			// Stat follows symlinks, as used by mounted ConfigMaps and Secrets
+			file := filepath.Join(path, entry.Name())
+			if info, err := os.Stat(file); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			files = appendUnique(files, file)
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("kubeconfig directory %s contains no files", path)
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no kubeconfig path given")
+	}
+	return files, nil
+}
+
+// appendUnique appends the file unless the list already holds it
+func appendUnique(files []string, file string) []string {
+	if slices.Contains(files, file) {
+		return files
+	}
+	return append(files, file)
+}
+
+// JoinKubeconfigPaths joins kubeconfig files into a single path list, separated like $KUBECONFIG
+func JoinKubeconfigPaths(files []string) string {
+	return strings.Join(files, string(filepath.ListSeparator))
+}
+
+// GetKubeconfigSources reads the files a kubeconfig path is merged from, recording
+// where each context is defined and which names collide. As with $KUBECONFIG, the
+// first file defining a name wins.
+func GetKubeconfigSources(kubeconfigPath string) (*KubeconfigSources, error) {
+	sources := &KubeconfigSources{Contexts: make(map[string]string)}
+	clusters := make(map[string][]string)
+	users := make(map[string][]string)
+	contexts := make(map[string][]string)
+
+	for _, file := range LoadingRules(kubeconfigPath).GetLoadingPrecedence() {
+		config, err := clientcmd.LoadFromFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to load kubeconfig file(%s): %v", file, err)
+		}
+		sources.Files = append(sources.Files, file)
+
+		for name := range config.Contexts {
+			contexts[name] = append(contexts[name], file)
+			if _, ok := sources.Contexts[name]; !ok {
+				sources.Contexts[name] = file
+			}
+		}
+		for name := range config.Clusters {
+			clusters[name] = append(clusters[name], file)
+		}
+		for name := range config.AuthInfos {
+			users[name] = append(users[name], file)
+		}
+	}
+
+	for _, kind := range []struct {
+		name  string
+		files map[string][]string
+	}{
+		{"context", contexts},
+		{"cluster", clusters},
+		{"user", users},
+	} {
+		for name, files := range kind.files {
+			if len(files) > 1 {
+				sources.Collisions = append(sources.Collisions, fmt.Sprintf("%s %q is defined in %s, the definition in %s is used",
+					kind.name, name, strings.Join(files, ", "), files[0]))
+			}
+		}
+	}
+	sort.Strings(sources.Collisions)
+	return sources, nil
+}
@@ -0,0 +1,214 @@
+package clientset
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce gathers the several events an editor or credential helper causes
+// when rewriting a file into a single reload
+const watchDebounce = 500 * time.Millisecond
+
+// kubeconfigWatcher watches the kubeconfig files of the pooled clients and drops the
+// clients whose cluster, user or context changed
+type kubeconfigWatcher struct {
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	dirs    map[string]bool
+	files   map[string]bool
+	pending map[string]bool
+	timer   *time.Timer
+}
+
+var (
+	watcher     *kubeconfigWatcher
+	watcherOnce sync.Once
+)
+
+// watchKubeconfig starts watching the kubeconfig files the pool key is loaded from
+func watchKubeconfig(key poolKey) {
+	if usesInCluster(key.kubeconfigPath) {
+		return
+	}
+
+	watcherOnce.Do(func() {
+		fsWatcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			slog.Warn("Unable to watch kubeconfig files, changes need set_kubeconfig_path to take effect", "error", err)
+			return
+		}
+		watcher = &kubeconfigWatcher{
+			watcher: fsWatcher,
+			dirs:    make(map[string]bool),
+			files:   make(map[string]bool),
+			pending: make(map[string]bool),
+		}
+		go watcher.run()
+	})
+	if watcher == nil {
+		return
+	}
+
+	for _, path := range kubeconfigFiles(key.kubeconfigPath) {
+		watcher.add(path)
+	}
+}
+
+// kubeconfigFiles returns the absolute paths of the files a kubeconfig path is loaded from
+func kubeconfigFiles(kubeconfigPath string) []string {
+	var files []string
+	for _, path := range LoadingRules(kubeconfigPath).GetLoadingPrecedence() {
+		if abs, err := filepath.Abs(path); err == nil {
+			files = append(files, abs)
+		}
+	}
+	return files
+}
+
+// add watches the file through its directory, so that files replaced by a rename or
+// created later are noticed too
+func (w *kubeconfigWatcher) add(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.files[path] {
+		return
+	}
+	dir := filepath.Dir(path)
+	if !w.dirs[dir] {
+		if err := w.watcher.Add(dir); err != nil {
+			slog.Warn("Unable to watch kubeconfig directory", "path", dir, "error", err)
+			return
+		}
+		w.dirs[dir] = true
+	}
+	w.files[path] = true
+	slog.Debug("Watching kubeconfig file", "path", path)
+}
+
+// run collects the events of watched files and reloads them once they settle
+func (w *kubeconfigWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.changed(event)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("Kubeconfig watch error", "error", err)
+		}
+	}
+}
+
+// changed marks the files affected by the event for reload
+func (w *kubeconfigWatcher) changed(event fsnotify.Event) {
+	if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	name := filepath.Clean(event.Name)
+	switch {
+	case w.files[name]:
+		w.pending[name] = true
+	case filepath.Base(name) == "..data":
+		// Mounted ConfigMaps and Secrets swap all their files through the ..data symlink
+		for path := range w.files {
+			if filepath.Dir(path) == filepath.Dir(name) {
+				w.pending[path] = true
+			}
+		}
+	default:
+		return
+	}
+
+	if w.timer == nil {
+		w.timer = time.AfterFunc(watchDebounce, w.reload)
+	} else {
+		w.timer.Reset(watchDebounce)
+	}
+}
+
+// reload revalidates the kubeconfigs merged from the changed files and drops the clients
+// whose configuration changed. A kubeconfig failing validation keeps the clients built
+// from its previous version.
+func (w *kubeconfigWatcher) reload() {
+	w.mu.Lock()
+	changed := make([]string, 0, len(w.pending))
+	for path := range w.pending {
+		changed = append(changed, path)
+	}
+	w.pending = make(map[string]bool)
+	w.mu.Unlock()
+
+	// A file may hold only part of a merged kubeconfig, so the merged result is validated
+	var valid []string
+	for _, kubeconfigPath := range pool.kubeconfigPaths(changed) {
+		if err := ValidateAndFixKubeconfig(kubeconfigPath); err != nil {
+			slog.Error("Ignoring invalid kubeconfig change, keeping the clients of the previous version",
+				"kubeconfig", kubeconfigPath, "changed", changed, "error", err)
+			continue
+		}
+		valid = append(valid, kubeconfigPath)
+	}
+	if len(valid) == 0 {
+		return
+	}
+
+	for _, key := range pool.invalidateChanged(valid) {
+		slog.Info("Kubeconfig changed, dropped cached clients", "kubeconfig", key.kubeconfigPath, "context", key.contextName)
+	}
+}
+
+// kubeconfigFingerprint captures the context, cluster and user a pool key is built from,
+// so that a change of an unrelated part of the kubeconfig keeps its clients
+func kubeconfigFingerprint(key poolKey) (string, error) {
+	if usesInCluster(key.kubeconfigPath) {
+		return "", nil
+	}
+
+	config, err := LoadingRules(key.kubeconfigPath).Load()
+	if err != nil {
+		return "", err
+	}
+	contextName := key.contextName
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+	kubeContext, ok := config.Contexts[contextName]
+	if !ok {
+		return "", fmt.Errorf("context %s not found", contextName)
+	}
+
+	data, err := json.Marshal([]any{kubeContext, config.Clusters[kubeContext.Cluster], config.AuthInfos[kubeContext.AuthInfo]})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// loadsFrom reports whether the pool key is loaded from any of the files
+func loadsFrom(key poolKey, files []string) bool {
+	if usesInCluster(key.kubeconfigPath) {
+		return false
+	}
+	for _, path := range kubeconfigFiles(key.kubeconfigPath) {
+		if slices.Contains(files, path) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/beastpu/mcp-k8s-sse-server/biz"
 	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/policy"
 
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 	"github.com/ThinkInAIXYZ/go-mcp/server"
@@ -32,6 +33,7 @@ func NewConfigMapHandler() (*ConfigMapHandler, error) {
 		"get_configmap",
 		"Get ConfigMap Content",
 		struct {
+			Context       string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace     string `json:"namespace" description:"Namespace of the ConfigMap, default is 'default'" required:"true"`
 			ConfigMapName string `json:"configMapName" description:"Name of the ConfigMap" required:"true"`
 		}{},
@@ -45,6 +47,7 @@ func NewConfigMapHandler() (*ConfigMapHandler, error) {
 		"list_configmaps",
 		"List All ConfigMaps",
 		struct {
+			Context   string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace string `json:"namespace" description:"Namespace of ConfigMaps, if empty will list from all namespaces" required:"false"`
 		}{},
 	)
@@ -54,6 +57,11 @@ func NewConfigMapHandler() (*ConfigMapHandler, error) {
 
 	tools[getConfigMapTool] = c.getConfigMap
 	tools[listConfigMapsTool] = c.listConfigMaps
+	biz.DeclareGroup("configmap", tools)
+
+	// Declare the namespaces targeted when the namespace argument is omitted
+	biz.DeclareDefaultNamespace("default", getConfigMapTool)
+	biz.DeclareDefaultNamespace(policy.AllNamespaces, listConfigMapsTool)
 	return c, nil
 }
 
@@ -66,7 +74,7 @@ func (c *ConfigMapHandler) GetTools() (map[*protocol.Tool]server.ToolHandlerFunc
 }
 
 // Handle get_configmap tool
-func (c *ConfigMapHandler) getConfigMap(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (c *ConfigMapHandler) getConfigMap(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[ConfigMapParams](req)
 	if err != nil {
 		return nil, err
@@ -78,13 +86,13 @@ func (c *ConfigMapHandler) getConfigMap(_ context.Context, req *protocol.CallToo
 	}
 
 	// Get the latest clientset
-	clientset, err := kubeclient.GetKubeClient()
+	clientset, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get ConfigMap
-	configMapContent, err := c.getConfigMapContent(clientset, params.Namespace, params.ConfigMapName)
+	configMapContent, err := c.getConfigMapContent(ctx, clientset, params.Namespace, params.ConfigMapName)
 	if err != nil {
 		return nil, err
 	}
@@ -100,20 +108,20 @@ func (c *ConfigMapHandler) getConfigMap(_ context.Context, req *protocol.CallToo
 }
 
 // Handle list_configmaps tool
-func (c *ConfigMapHandler) listConfigMaps(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (c *ConfigMapHandler) listConfigMaps(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[ListConfigMapsParams](req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get the latest clientset
-	clientset, err := kubeclient.GetKubeClient()
+	clientset, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// List ConfigMaps
-	configMapsList, err := c.listConfigMapsContent(clientset, params.Namespace)
+	configMapsList, err := c.listConfigMapsContent(ctx, clientset, params.Namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -129,9 +137,9 @@ func (c *ConfigMapHandler) listConfigMaps(_ context.Context, req *protocol.CallT
 }
 
 // Get ConfigMap content
-func (c *ConfigMapHandler) getConfigMapContent(clientset kubernetes.Interface, namespace, name string) (string, error) {
+func (c *ConfigMapHandler) getConfigMapContent(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (string, error) {
 
-	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to get ConfigMap %s in namespace %s: %v", name, namespace, err)
 	}
@@ -140,10 +148,10 @@ func (c *ConfigMapHandler) getConfigMapContent(clientset kubernetes.Interface, n
 }
 
 // List ConfigMaps content
-func (c *ConfigMapHandler) listConfigMapsContent(clientset kubernetes.Interface, namespace string) (string, error) {
+func (c *ConfigMapHandler) listConfigMapsContent(ctx context.Context, clientset kubernetes.Interface, namespace string) (string, error) {
 	if namespace != "" {
 		// List ConfigMaps in the specified namespace
-		configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{})
+		configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
 		if err != nil {
 			return "", fmt.Errorf("failed to list ConfigMaps in namespace %s: %v", namespace, err)
 		}
@@ -154,7 +162,7 @@ func (c *ConfigMapHandler) listConfigMapsContent(clientset kubernetes.Interface,
 			biz.FormatConfigMapsTable(configMaps.Items)), nil
 	} else {
 		// List ConfigMaps across all namespaces
-		configMaps, err := clientset.CoreV1().ConfigMaps("").List(context.TODO(), metav1.ListOptions{})
+		configMaps, err := clientset.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
 		if err != nil {
 			return "", fmt.Errorf("failed to list ConfigMaps across all namespaces: %v", err)
 		}
@@ -0,0 +1,231 @@
+package biz
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"sync"
+	"time"
+
+	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/session"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+)
+
+const (
+	// DefaultConfirmationTTL is how long a confirmation token stays valid
+	DefaultConfirmationTTL = 2 * time.Minute
+
+	// confirmationTokenArgument is the argument carrying the confirmation token of the second call
+	confirmationTokenArgument = "confirmationToken"
+)
+
+// ErrInvalidConfirmation is returned for calls presenting an unknown, expired or mismatching token
+var ErrInvalidConfirmation = errors.New("invalid or expired confirmation token, or the arguments or cluster context changed since the preview, call the tool without confirmationToken to get a new preview")
+
+// PreviewFunc describes what a call of a tool would change, including the current state of the affected object
+type PreviewFunc func(ctx context.Context, req *protocol.CallToolRequest) (string, error)
+
+// pendingConfirmation is a confirmation token handed out with a preview
+type pendingConfirmation struct {
+	session string
+	tool    string
+	digest  string
+	cluster string
+	expires time.Time
+}
+
+var confirmations = struct {
+	sync.Mutex
+	patterns []string
+	ttl      time.Duration
+	pending  map[string]pendingConfirmation
+}{
+	ttl:     DefaultConfirmationTTL,
+	pending: make(map[string]pendingConfirmation),
+}
+
+// SetConfirmTools sets the tool patterns of the tools that run only once a preview has been
+// confirmed. It must be called before the tools are registered.
+func SetConfirmTools(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid tool pattern %q: %v", pattern, err)
+		}
+	}
+
+	confirmations.Lock()
+	defer confirmations.Unlock()
+	confirmations.patterns = patterns
+	return nil
+}
+
+// SetConfirmationTTL sets how long confirmation tokens stay valid
+func SetConfirmationTTL(ttl time.Duration) {
+	confirmations.Lock()
+	defer confirmations.Unlock()
+	confirmations.ttl = ttl
+}
+
+// requiresConfirmation reports whether the named tool runs only after a confirmed preview
+func requiresConfirmation(name string) bool {
+	confirmations.Lock()
+	patterns := confirmations.patterns
+	confirmations.Unlock()
+	if len(patterns) == 0 {
+		return false
+	}
+
+	toolSpecs.RLock()
+	defer toolSpecs.RUnlock()
+	s, ok := toolSpecs.specs[name]
+	if !ok {
+		s = &toolSpec{}
+	}
+	return matchAny(patterns, s.identifiers(name))
+}
+
+// withConfirmationArgument returns a copy of the tool accepting the confirmation token argument
+func withConfirmationArgument(tool *protocol.Tool) *protocol.Tool {
+	confirmed := *tool
+	// Schemas generated from the same struct share their properties map
+	confirmed.InputSchema.Properties = maps.Clone(tool.InputSchema.Properties)
+	if confirmed.InputSchema.Properties == nil {
+		confirmed.InputSchema.Properties = make(map[string]*protocol.Property)
+	}
+	confirmed.InputSchema.Properties[confirmationTokenArgument] = &protocol.Property{
+		Type:        protocol.String,
+		Description: "Token returned by the preview of a first call without it. The call only runs when repeated with the same arguments and this token",
+	}
+	return &confirmed
+}
+
+// withConfirmation makes the named tool answer a first call with a preview and a confirmation
+// token, and run only when called again from the same session with the same arguments and
+// token, against the same cluster
+func withConfirmation(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		var arguments map[string]any
+		if err := json.Unmarshal(req.RawArguments, &arguments); err != nil {
+			return nil, fmt.Errorf("failed to parse parameters: %v", err)
+		}
+		// A dry run changes nothing, so it rehearses the call without confirmation
+		if dryRun, _ := arguments[dryRunArgument].(bool); dryRun && supportsDryRun(name) {
+			return next(ctx, req)
+		}
+
+		token, _ := arguments[confirmationTokenArgument].(string)
+		delete(arguments, confirmationTokenArgument)
+		digest, err := argumentsDigest(arguments)
+		if err != nil {
+			return nil, err
+		}
+		// The token is only valid for the cluster the preview looked at, even if the
+		// session switches context or kubeconfig in between
+		contextName, err := kubeclient.ResolveContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cluster := kubeclient.GetCustomKubeconfigPath(ctx) + "\n" + contextName
+
+		if token != "" {
+			if !confirm(token, session.IDFromContext(ctx), name, digest, cluster) {
+				return nil, ErrInvalidConfirmation
+			}
+			return next(ctx, req)
+		}
+
+		preview, err := previewCall(ctx, name, req)
+		if err != nil {
+			return nil, err
+		}
+		token, ttl, err := issueConfirmation(session.IDFromContext(ctx), name, digest, cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		text := fmt.Sprintf("Confirmation required, %s has not been run.\n\n", name)
+		text += fmt.Sprintf("Cluster context: %s\n", contextName)
+		text += preview + "\n\n" + fmt.Sprintf("To proceed, call %s again with the same arguments and \"%s\": %q. "+
+			"The token expires in %s and is valid once, for this session only.", name, confirmationTokenArgument, token, ttl)
+		return &protocol.CallToolResult{
+			Content: []protocol.Content{
+				protocol.TextContent{
+					Type: "text",
+					Text: text,
+				},
+			},
+		}, nil
+	}
+}
+
+// previewCall runs the preview declared for the tool, or lists the arguments if there is none
+func previewCall(ctx context.Context, name string, req *protocol.CallToolRequest) (string, error) {
+	toolSpecs.RLock()
+	s, ok := toolSpecs.specs[name]
+	toolSpecs.RUnlock()
+	if ok && s.preview != nil {
+		return s.preview(ctx, req)
+	}
+	return fmt.Sprintf("Will call %s with arguments %s", name, req.RawArguments), nil
+}
+
+// issueConfirmation hands out a token confirming the call, dropping expired tokens
+func issueConfirmation(sessionID, tool, digest, cluster string) (string, time.Duration, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", 0, fmt.Errorf("failed to create confirmation token: %v", err)
+	}
+	token := hex.EncodeToString(b)
+
+	confirmations.Lock()
+	defer confirmations.Unlock()
+	now := time.Now()
+	for t, p := range confirmations.pending {
+		if now.After(p.expires) {
+			delete(confirmations.pending, t)
+		}
+	}
+	confirmations.pending[token] = pendingConfirmation{
+		session: sessionID,
+		tool:    tool,
+		digest:  digest,
+		cluster: cluster,
+		expires: now.Add(confirmations.ttl),
+	}
+	return token, confirmations.ttl, nil
+}
+
+// confirm consumes the token if it was issued for the same session, tool, arguments and
+// cluster and has not expired
+func confirm(token, sessionID, tool, digest, cluster string) bool {
+	confirmations.Lock()
+	defer confirmations.Unlock()
+	p, ok := confirmations.pending[token]
+	if !ok {
+		return false
+	}
+	if p.session != sessionID || p.tool != tool || p.digest != digest || p.cluster != cluster {
+		return false
+	}
+	delete(confirmations.pending, token)
+	return time.Now().Before(p.expires)
+}
+
+// argumentsDigest hashes the arguments of a call, encoding/json sorting the map keys
+func argumentsDigest(arguments map[string]any) (string, error) {
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
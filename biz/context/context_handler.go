@@ -3,11 +3,13 @@ package context
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/beastpu/mcp-k8s-sse-server/biz"
 
 	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/logging"
 
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 	"github.com/ThinkInAIXYZ/go-mcp/server"
@@ -33,7 +35,8 @@ func NewContextHandler() (*ContextHandler, error) {
 		"set_kubeconfig_path",
 		"Set Custom Kubeconfig File Path",
 		struct {
-			KubeconfigPath string `json:"kubeconfigPath" description:"Path to the kubeconfig file" required:"true"`
+			KubeconfigPath  string   `json:"kubeconfigPath" description:"Path to the kubeconfig file, or to a directory whose files are all merged, e.g. ~/.kube/clusters/"`
+			KubeconfigPaths []string `json:"kubeconfigPaths" description:"Kubeconfig files or directories merged in order like $KUBECONFIG, instead of kubeconfigPath"`
 		}{},
 	)
 	if err != nil {
@@ -66,6 +69,7 @@ func NewContextHandler() (*ContextHandler, error) {
 		"Switch to Specified Kubernetes Context",
 		struct {
 			ContextName string `json:"contextName" description:"Name of the context to switch to" required:"true"`
+			Persist     bool   `json:"persist" description:"Also write current-context to the kubeconfig file, which affects kubectl and every other client using it. Default false, switching only this session"`
 		}{},
 	)
 	if err != nil {
@@ -76,6 +80,10 @@ func NewContextHandler() (*ContextHandler, error) {
 	tools[getCurrentContextTool] = c.getCurrentContext
 	tools[listContextsTool] = c.listContexts
 	tools[switchContextTool] = c.switchContext
+	biz.DeclareGroup("context", tools)
+
+	// Declare tools that change server or kubeconfig state
+	biz.DeclareMutating(setKubeconfigPathTool, switchContextTool)
 
 	return c, nil
 }
@@ -89,13 +97,21 @@ func (c *ContextHandler) GetTools() (map[*protocol.Tool]server.ToolHandlerFunc,
 }
 
 // Handle set_kubeconfig_path tool
-func (c *ContextHandler) setKubeconfigPath(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (c *ContextHandler) setKubeconfigPath(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[KubeconfigPathParams](req)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := c.setKubeconfigPathInternal(params.KubeconfigPath)
+	paths := params.KubeconfigPaths
+	if params.KubeconfigPath != "" {
+		paths = append([]string{params.KubeconfigPath}, paths...)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("kubeconfigPath or kubeconfigPaths is required")
+	}
+
+	result, err := c.setKubeconfigPathInternal(ctx, paths)
 	if err != nil {
 		return nil, err
 	}
@@ -111,8 +127,8 @@ func (c *ContextHandler) setKubeconfigPath(_ context.Context, req *protocol.Call
 }
 
 // Handle get_current_context tool
-func (c *ContextHandler) getCurrentContext(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
-	currentCtx, err := kubeclient.GetCurrentContext()
+func (c *ContextHandler) getCurrentContext(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	currentCtx, err := kubeclient.GetCurrentContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -128,8 +144,8 @@ func (c *ContextHandler) getCurrentContext(_ context.Context, req *protocol.Call
 }
 
 // Handle list_contexts tool
-func (c *ContextHandler) listContexts(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
-	contexts, err := c.listContextsInternal()
+func (c *ContextHandler) listContexts(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	contexts, err := c.listContextsInternal(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -145,13 +161,13 @@ func (c *ContextHandler) listContexts(_ context.Context, req *protocol.CallToolR
 }
 
 // Handle switch_context tool
-func (c *ContextHandler) switchContext(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (c *ContextHandler) switchContext(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[ContextNameParams](req)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := c.switchContextInternal(params.ContextName)
+	result, err := c.switchContextInternal(ctx, params.ContextName, params.Persist)
 	if err != nil {
 		return nil, err
 	}
@@ -166,87 +182,97 @@ func (c *ContextHandler) switchContext(_ context.Context, req *protocol.CallTool
 	}, nil
 }
 
-// Set custom kubeconfig path
-func (c *ContextHandler) setKubeconfigPathInternal(kubeconfigPath string) (string, error) {
-	fmt.Printf("Debug - Attempting to set kubeconfig path: %s\n", kubeconfigPath)
+// Set custom kubeconfig path, merging several files or the files of a directory
+func (c *ContextHandler) setKubeconfigPathInternal(ctx context.Context, paths []string) (string, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("Attempting to set kubeconfig path", "paths", paths)
 
-	// Validate and try to fix kubeconfig file
+	files, err := kubeclient.ExpandKubeconfigPaths(paths)
+	if err != nil {
+		return "", fmt.Errorf("kubeconfig validation failed: %v", err)
+	}
+
+	// Validate the files and their merged configuration, a file may hold only clusters or users
+	kubeconfigPath := kubeclient.JoinKubeconfigPaths(files)
 	if err := kubeclient.ValidateAndFixKubeconfig(kubeconfigPath); err != nil {
 		return "", fmt.Errorf("kubeconfig validation failed: %v", err)
 	}
 
+	sources, err := kubeclient.GetKubeconfigSources(kubeconfigPath)
+	if err != nil {
+		return "", fmt.Errorf("kubeconfig validation failed: %v", err)
+	}
+
 	// Save new kubeconfig path
-	kubeclient.SetCustomKubeconfigPath(kubeconfigPath)
+	kubeclient.SetCustomKubeconfigPath(ctx, kubeconfigPath)
 
 	// Reset current context, as the new kubeconfig may have a different current context
-	kubeclient.ResetCurrentContext()
+	kubeclient.ResetCurrentContext(ctx)
 
-	// Clear client cache
-	kubeclient.ClearClientCache()
+	// Drop clients built from a previous version of this kubeconfig file
+	kubeclient.InvalidateClients(kubeconfigPath)
 
 	// Try to get the current context from the new kubeconfig file
-	ctx, err := kubeclient.GetCurrentContext()
+	currentCtx, err := kubeclient.GetCurrentContext(ctx)
 	if err != nil {
 		return "", fmt.Errorf("could not get current context after setting kubeconfig path: %v", err)
 	}
 
-	// Output debug info
-	fmt.Printf("Debug - Context after setting kubeconfig path: %s\n", ctx)
+	logger.Debug("Context after setting kubeconfig path", "currentContext", currentCtx)
 
 	// Get new kubeconfig configuration information
-	configAfterSet, err := kubeclient.GetKubeConfig()
+	configAfterSet, err := kubeclient.GetKubeConfig(ctx)
 	if err != nil {
 		return "", fmt.Errorf("could not reload kubeconfig file: %v", err)
 	}
 
-	// Handle case where ctx is empty
-	contextInfo := ctx
-	if ctx == "" {
+	// Handle case where currentCtx is empty
+	contextInfo := currentCtx
+	if currentCtx == "" {
 		if len(configAfterSet.Contexts) > 0 {
 			// If there are available contexts but current context is empty,
 			// try to automatically switch to the first context
 			for name := range configAfterSet.Contexts {
-				// Update the current context in config
-				configAfterSet.CurrentContext = name
-
-				// Create configuration accessor
-				configAccess := &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeclient.GetCustomKubeconfigPath()}
-
-				// Write the modified configuration back to file
-				if writeErr := clientcmd.ModifyConfig(configAccess, *configAfterSet, true); writeErr != nil {
-					return "", fmt.Errorf("failed to save kubeconfig file: %v", writeErr)
-				}
-
-				// Set current context
-				kubeclient.SetCurrentContext(name)
-
-				// Clear client cache
-				kubeclient.ClearClientCache()
+				// Set current context in memory only, the kubeconfig file is left untouched
+				kubeclient.SetCurrentContext(ctx, name)
 
 				contextInfo = name
-				fmt.Printf("Debug - Automatically switched to first available context: %s\n", name)
+				logger.Debug("Automatically switched to first available context", "currentContext", name)
 				break
 			}
 		}
 	}
 
-	return fmt.Sprintf("Kubeconfig path has been set to %s, current context: %s", kubeconfigPath, contextInfo), nil
+	result := fmt.Sprintf("Kubeconfig path has been set to %s, current context: %s", strings.Join(files, ", "), contextInfo)
+	if len(sources.Collisions) > 0 {
+		result += "\n\nWarning, names defined in several files:\n  " + strings.Join(sources.Collisions, "\n  ")
+	}
+	return result, nil
 }
 
 // List all available contexts
-func (c *ContextHandler) listContextsInternal() (string, error) {
+func (c *ContextHandler) listContextsInternal(ctx context.Context) (string, error) {
 	// Get kubeconfig configuration
-	config, err := kubeclient.GetKubeConfig()
+	config, err := kubeclient.GetKubeConfig(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	// Get current context
-	currentCtx, err := kubeclient.GetCurrentContext()
+	currentCtx, err := kubeclient.GetCurrentContext(ctx)
 	if err != nil {
 		return "", err
 	}
 
+	// Find the file of each context when several files are merged
+	var sources *kubeclient.KubeconfigSources
+	if !kubeclient.UsesInCluster(ctx) {
+		sources, err = kubeclient.GetKubeconfigSources(kubeclient.GetCustomKubeconfigPath(ctx))
+		if err != nil {
+			return "", err
+		}
+	}
+
 	// Format output
 	var sb strings.Builder
 	sb.WriteString("Available Kubernetes contexts:\n")
@@ -267,14 +293,26 @@ func (c *ContextHandler) listContextsInternal() (string, error) {
 			sb.WriteString(fmt.Sprintf("    Cluster: %s\n", ctx.Cluster))
 			sb.WriteString(fmt.Sprintf("    User: %s\n", ctx.AuthInfo))
 			sb.WriteString(fmt.Sprintf("    Namespace: %s\n", ctx.Namespace))
+			if sources != nil && len(sources.Files) > 1 {
+				sb.WriteString(fmt.Sprintf("    Source: %s\n", sources.Contexts[name]))
+			}
 			sb.WriteString("\n")
 		}
 	}
 
+	if sources != nil && len(sources.Collisions) > 0 {
+		sb.WriteString("Names defined in several kubeconfig files:\n")
+		for _, collision := range sources.Collisions {
+			sb.WriteString(fmt.Sprintf("  %s\n", collision))
+		}
+	}
+
 	// Add path info
-	customPath := kubeclient.GetCustomKubeconfigPath()
-	if customPath != "" {
-		sb.WriteString(fmt.Sprintf("\nUsing custom kubeconfig: %s\n", customPath))
+	customPath := kubeclient.GetCustomKubeconfigPath(ctx)
+	if kubeclient.UsesInCluster(ctx) {
+		sb.WriteString("\nUsing in-cluster ServiceAccount credentials\n")
+	} else if customPath != "" {
+		sb.WriteString(fmt.Sprintf("\nUsing custom kubeconfig: %s\n", strings.Join(filepath.SplitList(customPath), ", ")))
 	} else {
 		sb.WriteString("\nUsing default kubeconfig path\n")
 	}
@@ -282,10 +320,15 @@ func (c *ContextHandler) listContextsInternal() (string, error) {
 	return sb.String(), nil
 }
 
-// Switch to specified context
-func (c *ContextHandler) switchContextInternal(contextName string) (string, error) {
+// Switch to specified context, optionally persisting it as current-context in the kubeconfig file
+func (c *ContextHandler) switchContextInternal(ctx context.Context, contextName string, persist bool) (string, error) {
+	// The synthetic in-cluster context has no kubeconfig file to write to
+	if persist && kubeclient.UsesInCluster(ctx) {
+		return "", fmt.Errorf("context cannot be persisted in in-cluster mode, there is no kubeconfig file")
+	}
+
 	// Get kubeconfig configuration
-	config, err := kubeclient.GetKubeConfig()
+	config, err := kubeclient.GetKubeConfig(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -296,38 +339,33 @@ func (c *ContextHandler) switchContextInternal(contextName string) (string, erro
 	}
 
 	// Get current context for comparison
-	currentCtx, err := kubeclient.GetCurrentContext()
+	currentCtx, err := kubeclient.GetCurrentContext(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	// If already using the requested context, just return
-	if currentCtx == contextName {
-		return fmt.Sprintf("Already using context '%s'", contextName), nil
+	// If already using the requested context and nothing has to be written, just return
+	if currentCtx == contextName && !persist {
+		return fmt.Sprintf("Already using context '%s' (this session only, kubeconfig file unchanged)", contextName), nil
+	}
+
+	// Set current context in memory, clients of the new context come from the client pool
+	kubeclient.SetCurrentContext(ctx, contextName)
+
+	if !persist {
+		return fmt.Sprintf("Switched to context '%s' for this session only, kubeconfig file unchanged", contextName), nil
 	}
 
 	// Set the current context in configuration
 	config.CurrentContext = contextName
 
 	// Create configuration accessor
-	var configAccess clientcmd.ConfigAccess
-	customPath := kubeclient.GetCustomKubeconfigPath()
-	if customPath != "" {
-		configAccess = &clientcmd.ClientConfigLoadingRules{ExplicitPath: customPath}
-	} else {
-		configAccess = clientcmd.NewDefaultClientConfigLoadingRules()
-	}
+	configAccess := kubeclient.LoadingRules(kubeclient.GetCustomKubeconfigPath(ctx))
 
 	// Update kubeconfig file
 	if err := clientcmd.ModifyConfig(configAccess, *config, true); err != nil {
-		return "", fmt.Errorf("failed to modify kubeconfig: %v", err)
+		return "", fmt.Errorf("switched to context '%s' for this session, but failed to persist it to kubeconfig: %v", contextName, err)
 	}
 
-	// Set current context in memory
-	kubeclient.SetCurrentContext(contextName)
-
-	// Clear client cache to ensure using the new context
-	kubeclient.ClearClientCache()
-
-	return fmt.Sprintf("Switched to context '%s'", contextName), nil
+	return fmt.Sprintf("Switched to context '%s' and persisted it as current-context in kubeconfig file %s", contextName, configAccess.GetDefaultFilename()), nil
 }
@@ -2,10 +2,12 @@ package context
 
 // KubeconfigPathParams defines parameters for setting kubeconfig path
 type KubeconfigPathParams struct {
-	KubeconfigPath string `json:"kubeconfigPath"`
+	KubeconfigPath  string   `json:"kubeconfigPath"`
+	KubeconfigPaths []string `json:"kubeconfigPaths"`
 }
 
 // ContextNameParams defines Context name parameters
 type ContextNameParams struct {
 	ContextName string `json:"contextName"`
+	Persist     bool   `json:"persist"`
 }
@@ -0,0 +1,30 @@
+package biz
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// dryRunArgument is the argument requesting a server-side dry run of a write tool
+const dryRunArgument = "dryRun"
+
+// DryRun returns the DryRun option of a Kubernetes write request, running all
+// admission and validation stages without persisting anything when dryRun is set
+func DryRun(dryRun bool) []string {
+	if dryRun {
+		return []string{metav1.DryRunAll}
+	}
+	return nil
+}
+
+// DryRunError describes a write request the API server rejected during a dry run,
+// e.g. because of validation or an admission webhook
+func DryRunError(err error) error {
+	return fmt.Errorf("dry run: the API server rejected the request, nothing was changed: %w", err)
+}
+
+// DryRunResult prefixes the outcome of a dry run, making clear that nothing was changed
+func DryRunResult(format string, args ...any) string {
+	return "Dry run, nothing was changed: " + fmt.Sprintf(format, args...)
+}
@@ -127,11 +127,17 @@ func FormatPodsTable(pods []corev1.Pod) string {
 		// Get detailed Pod status
 		status := GetPodStatus(&pod)
 
+		// Pending Pods have not been started yet
+		startTime := "<none>"
+		if pod.Status.StartTime != nil {
+			startTime = pod.Status.StartTime.Format("2006-01-02 15:04:05")
+		}
+
 		sb.WriteString(fmt.Sprintf("%s\t%s\t%s\t%s\t%s\n",
 			pod.Namespace,
 			pod.Name,
 			status,
-			pod.Status.StartTime.Format("2006-01-02 15:04:05"),
+			startTime,
 			pod.Status.PodIP))
 	}
 
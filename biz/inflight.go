@@ -0,0 +1,109 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+)
+
+// ErrShuttingDown is returned for tool calls received while the server drains
+var ErrShuttingDown = errors.New("server is shutting down, please retry")
+
+// inFlightCalls tracks running tool calls so that shutdown can wait for them
+type inFlightCalls struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+	nextID   uint64
+	cancels  map[uint64]context.CancelFunc
+}
+
+var inFlight = &inFlightCalls{cancels: make(map[uint64]context.CancelFunc)}
+
+// begin registers a tool call and returns its cancellable context and completion callback
+func (c *inFlightCalls) begin(ctx context.Context) (context.Context, func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.draining {
+		return nil, nil, ErrShuttingDown
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	id := c.nextID
+	c.nextID++
+	c.cancels[id] = cancel
+	c.wg.Add(1)
+
+	return ctx, func() {
+		c.mu.Lock()
+		delete(c.cancels, id)
+		c.mu.Unlock()
+		cancel()
+		c.wg.Done()
+	}, nil
+}
+
+// wait waits for all tool calls to finish, returning false if the timeout expires first
+func (c *inFlightCalls) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// DrainToolCalls rejects new tool calls and waits up to timeout for running ones to finish.
+// Calls still running after the timeout get their context cancelled and are given
+// gracePeriod to return. It returns the number of cancelled calls.
+func DrainToolCalls(timeout, gracePeriod time.Duration) int {
+	inFlight.mu.Lock()
+	inFlight.draining = true
+	inFlight.mu.Unlock()
+
+	if inFlight.wait(timeout) {
+		return 0
+	}
+
+	inFlight.mu.Lock()
+	cancelled := len(inFlight.cancels)
+	for _, cancel := range inFlight.cancels {
+		cancel()
+	}
+	inFlight.mu.Unlock()
+
+	inFlight.wait(gracePeriod)
+	return cancelled
+}
+
+// trackInFlight registers every call of the tool with the in-flight tracker
+func trackInFlight(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		ctx, done, err := inFlight.begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer done()
+
+		result, err := next(ctx, req)
+		if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+			return nil, fmt.Errorf("tool call cancelled because the server is shutting down: %v", err)
+		}
+		return result, err
+	}
+}
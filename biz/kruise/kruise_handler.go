@@ -36,6 +36,7 @@ func NewKruiseHandler() (*KruiseHandler, error) {
 		"list_advanced_statefulsets",
 		"List AdvancedStatefulSets",
 		struct {
+			Context       string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace     string `json:"namespace" description:"Namespace of the resource, default is 'default'"`
 			AllNamespaces bool   `json:"allNamespaces" description:"Whether to list resources in all namespaces"`
 		}{},
@@ -49,6 +50,7 @@ func NewKruiseHandler() (*KruiseHandler, error) {
 		"list_clonesets",
 		"List CloneSets",
 		struct {
+			Context       string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace     string `json:"namespace" description:"Namespace of the resource, default is 'default'"`
 			AllNamespaces bool   `json:"allNamespaces" description:"Whether to list resources in all namespaces"`
 		}{},
@@ -62,10 +64,12 @@ func NewKruiseHandler() (*KruiseHandler, error) {
 		"scale_kruise_resource",
 		"Scale OpenKruise Resource Replicas",
 		struct {
+			Context      string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			ResourceType string `json:"resourceType" description:"Resource type, e.g. 'advancedstatefulset' or 'cloneset'" required:"true"`
 			Namespace    string `json:"namespace" description:"Namespace of the resource, default is 'default'"`
 			ResourceName string `json:"resourceName" description:"Name of the resource to scale" required:"true"`
 			Replicas     string `json:"replicas" description:"Number of replicas to scale to" required:"true"`
+			DryRun       bool   `json:"dryRun" description:"Only run the update through the API server's validation and admission, without scaling the resource"`
 		}{},
 	)
 	if err != nil {
@@ -77,10 +81,12 @@ func NewKruiseHandler() (*KruiseHandler, error) {
 		"scale",
 		"Scale OpenKruise Resource Replicas",
 		struct {
+			Context      string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			ResourceType string `json:"resourceType" description:"Resource type, e.g. 'advancedstatefulset' or 'cloneset'" required:"true"`
 			Namespace    string `json:"namespace" description:"Namespace of the resource, default is 'default'"`
 			ResourceName string `json:"resourceName" description:"Name of the resource to scale" required:"true"`
 			Replicas     string `json:"replicas" description:"Number of replicas to scale to" required:"true"`
+			DryRun       bool   `json:"dryRun" description:"Only run the update through the API server's validation and admission, without scaling the resource"`
 		}{},
 	)
 	if err != nil {
@@ -92,6 +98,7 @@ func NewKruiseHandler() (*KruiseHandler, error) {
 		"describe_advanced_statefulset",
 		"Describe AdvancedStatefulSet",
 		struct {
+			Context   string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace string `json:"namespace" description:"Namespace of the resource, default is 'default'"`
 			Name      string `json:"name" description:"Name of the resource" required:"true"`
 		}{},
@@ -105,6 +112,7 @@ func NewKruiseHandler() (*KruiseHandler, error) {
 		"describe_cloneset",
 		"Describe CloneSet",
 		struct {
+			Context   string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace string `json:"namespace" description:"Namespace of the resource, default is 'default'"`
 			Name      string `json:"name" description:"Name of the resource" required:"true"`
 		}{},
@@ -120,6 +128,17 @@ func NewKruiseHandler() (*KruiseHandler, error) {
 	tools[scaleTool] = k.scale
 	tools[describeAdvancedStatefulSetTool] = k.describeAdvancedStatefulSet
 	tools[describeCloneSetTool] = k.describeCloneSet
+	biz.DeclareGroup("kruise", tools)
+
+	// Declare tools that change cluster state
+	biz.DeclareMutating(scaleResourceTool, scaleTool)
+	biz.DeclareDryRun(scaleResourceTool, scaleTool)
+	biz.DeclarePreview(scaleResourceTool, k.previewScale)
+	biz.DeclarePreview(scaleTool, k.previewScale)
+
+	// Declare the namespace targeted when the namespace argument is omitted
+	biz.DeclareDefaultNamespace("default", listAdvancedStatefulSetsTool, listCloneSetsTool, scaleResourceTool, scaleTool,
+		describeAdvancedStatefulSetTool, describeCloneSetTool)
 
 	return k, nil
 }
@@ -132,13 +151,13 @@ func (k *KruiseHandler) GetTools() (map[*protocol.Tool]server.ToolHandlerFunc, e
 	return k.tools, nil
 }
 
-// Get the latest kruiseClient
-func (k *KruiseHandler) getKruiseClient() (kruiseclientset.Interface, error) {
-	return kubeclient.GetKruiseClient()
+// Get the latest kruiseClient of the calling session
+func (k *KruiseHandler) getKruiseClient(ctx context.Context) (kruiseclientset.Interface, error) {
+	return kubeclient.GetKruiseClient(ctx)
 }
 
 // Handle list_advanced_statefulsets tool
-func (k *KruiseHandler) listAdvancedStatefulSets(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (k *KruiseHandler) listAdvancedStatefulSets(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[KruiseNamespaceParams](req)
 	if err != nil {
 		return nil, err
@@ -149,8 +168,14 @@ func (k *KruiseHandler) listAdvancedStatefulSets(_ context.Context, req *protoco
 		params.Namespace = "default"
 	}
 
+	// Get the latest kruiseClient
+	kruiseClient, err := k.getKruiseClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// List AdvancedStatefulSets
-	output, err := k.listAdvancedStatefulSetsInternal(params.Namespace, params.AllNamespaces)
+	output, err := k.listAdvancedStatefulSetsInternal(ctx, kruiseClient, params.Namespace, params.AllNamespaces)
 	if err != nil {
 		return nil, err
 	}
@@ -166,7 +191,7 @@ func (k *KruiseHandler) listAdvancedStatefulSets(_ context.Context, req *protoco
 }
 
 // Handle list_clonesets tool
-func (k *KruiseHandler) listCloneSets(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (k *KruiseHandler) listCloneSets(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[KruiseNamespaceParams](req)
 	if err != nil {
 		return nil, err
@@ -177,8 +202,14 @@ func (k *KruiseHandler) listCloneSets(_ context.Context, req *protocol.CallToolR
 		params.Namespace = "default"
 	}
 
+	// Get the latest kruiseClient
+	kruiseClient, err := k.getKruiseClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// List CloneSets
-	output, err := k.listCloneSetsInternal(params.Namespace, params.AllNamespaces)
+	output, err := k.listCloneSetsInternal(ctx, kruiseClient, params.Namespace, params.AllNamespaces)
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +225,7 @@ func (k *KruiseHandler) listCloneSets(_ context.Context, req *protocol.CallToolR
 }
 
 // Handle scale_kruise_resource tool
-func (k *KruiseHandler) scaleResource(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (k *KruiseHandler) scaleResource(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[KruiseScaleParams](req)
 	if err != nil {
 		return nil, err
@@ -212,18 +243,24 @@ func (k *KruiseHandler) scaleResource(_ context.Context, req *protocol.CallToolR
 	}
 	replicas := int32(replicasInt)
 
+	// Get the latest kruiseClient
+	kruiseClient, err := k.getKruiseClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Choose appropriate handling function based on resource type
 	var output string
 
 	switch params.ResourceType {
 	case "advancedstatefulset", "advancedstatefulsets", "asts":
-		output, err = k.scaleAdvancedStatefulSet(params.Namespace, params.ResourceName, replicas)
+		output, err = k.scaleAdvancedStatefulSet(ctx, kruiseClient, params.Namespace, params.ResourceName, replicas, params.DryRun)
 		if err != nil {
 			return nil, err
 		}
 
 	case "cloneset", "clonesets":
-		output, err = k.scaleCloneSet(params.Namespace, params.ResourceName, replicas)
+		output, err = k.scaleCloneSet(ctx, kruiseClient, params.Namespace, params.ResourceName, replicas, params.DryRun)
 		if err != nil {
 			return nil, err
 		}
@@ -243,7 +280,7 @@ func (k *KruiseHandler) scaleResource(_ context.Context, req *protocol.CallToolR
 }
 
 // Handle generic scale tool
-func (k *KruiseHandler) scale(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (k *KruiseHandler) scale(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[KruiseScaleParams](req)
 	if err != nil {
 		return nil, err
@@ -261,18 +298,24 @@ func (k *KruiseHandler) scale(_ context.Context, req *protocol.CallToolRequest)
 	}
 	replicas := int32(replicasInt)
 
+	// Get the latest kruiseClient
+	kruiseClient, err := k.getKruiseClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Choose appropriate handling function based on resource type
 	var output string
 
 	switch params.ResourceType {
 	case "advancedstatefulset", "advancedstatefulsets", "asts":
-		output, err = k.scaleAdvancedStatefulSet(params.Namespace, params.ResourceName, replicas)
+		output, err = k.scaleAdvancedStatefulSet(ctx, kruiseClient, params.Namespace, params.ResourceName, replicas, params.DryRun)
 		if err != nil {
 			return nil, err
 		}
 
 	case "cloneset", "clonesets":
-		output, err = k.scaleCloneSet(params.Namespace, params.ResourceName, replicas)
+		output, err = k.scaleCloneSet(ctx, kruiseClient, params.Namespace, params.ResourceName, replicas, params.DryRun)
 		if err != nil {
 			return nil, err
 		}
@@ -292,7 +335,7 @@ func (k *KruiseHandler) scale(_ context.Context, req *protocol.CallToolRequest)
 }
 
 // Handle describe_advanced_statefulset tool
-func (k *KruiseHandler) describeAdvancedStatefulSet(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (k *KruiseHandler) describeAdvancedStatefulSet(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[KruiseDescribeParams](req)
 	if err != nil {
 		return nil, err
@@ -303,8 +346,14 @@ func (k *KruiseHandler) describeAdvancedStatefulSet(_ context.Context, req *prot
 		params.Namespace = "default"
 	}
 
+	// Get the latest kruiseClient
+	kruiseClient, err := k.getKruiseClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Describe AdvancedStatefulSet
-	output, err := k.describeAdvancedStatefulSetInternal(params.Namespace, params.Name)
+	output, err := k.describeAdvancedStatefulSetInternal(ctx, kruiseClient, params.Namespace, params.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -320,7 +369,7 @@ func (k *KruiseHandler) describeAdvancedStatefulSet(_ context.Context, req *prot
 }
 
 // Handle describe_cloneset tool
-func (k *KruiseHandler) describeCloneSet(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (k *KruiseHandler) describeCloneSet(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[KruiseDescribeParams](req)
 	if err != nil {
 		return nil, err
@@ -331,8 +380,14 @@ func (k *KruiseHandler) describeCloneSet(_ context.Context, req *protocol.CallTo
 		params.Namespace = "default"
 	}
 
+	// Get the latest kruiseClient
+	kruiseClient, err := k.getKruiseClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Describe CloneSet
-	output, err := k.describeCloneSetInternal(params.Namespace, params.Name)
+	output, err := k.describeCloneSetInternal(ctx, kruiseClient, params.Namespace, params.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -347,61 +402,110 @@ func (k *KruiseHandler) describeCloneSet(_ context.Context, req *protocol.CallTo
 	}, nil
 }
 
-// Scale AdvancedStatefulSet replicas
-func (k *KruiseHandler) scaleAdvancedStatefulSet(namespace, name string, replicas int32) (string, error) {
-	kruiseClient, err := k.getKruiseClient()
+// Describe the replica change scale or scale_kruise_resource would make
+func (k *KruiseHandler) previewScale(ctx context.Context, req *protocol.CallToolRequest) (string, error) {
+	params, err := biz.ParseParams[KruiseScaleParams](req)
+	if err != nil {
+		return "", err
+	}
+
+	// Set default namespace
+	if params.Namespace == "" {
+		params.Namespace = "default"
+	}
+
+	kruiseClient, err := k.getKruiseClient(ctx)
 	if err != nil {
 		return "", err
 	}
 
+	var kind, state string
+	var current *int32
+	switch params.ResourceType {
+	case "advancedstatefulset", "advancedstatefulsets", "asts":
+		ast, err := kruiseClient.AppsV1beta1().StatefulSets(params.Namespace).Get(ctx, params.ResourceName, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		kind, current = "AdvancedStatefulSet", ast.Spec.Replicas
+		state = biz.FormatAdvancedStatefulSetsTable([]appsv1beta1.StatefulSet{*ast})
+
+	case "cloneset", "clonesets":
+		cloneSet, err := kruiseClient.AppsV1alpha1().CloneSets(params.Namespace).Get(ctx, params.ResourceName, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		kind, current = "CloneSet", cloneSet.Spec.Replicas
+		state = biz.FormatCloneSetsTable([]appsv1alpha1.CloneSet{*cloneSet})
+
+	default:
+		return "", fmt.Errorf("unsupported resource type: %s", params.ResourceType)
+	}
+
+	from := "unset"
+	if current != nil {
+		from = strconv.Itoa(int(*current))
+	}
+	return fmt.Sprintf("Will scale %s %s in namespace %s from %s to %s replicas.\n\nCurrent state:\n%s",
+		kind, params.ResourceName, params.Namespace, from, params.Replicas, state), nil
+}
+
+// Scale AdvancedStatefulSet replicas
+func (k *KruiseHandler) scaleAdvancedStatefulSet(ctx context.Context, kruiseClient kruiseclientset.Interface, namespace, name string, replicas int32, dryRun bool) (string, error) {
 	// Get the AdvancedStatefulSet
-	ast, err := kruiseClient.AppsV1beta1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	ast, err := kruiseClient.AppsV1beta1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return "", err
 	}
 
 	// Update the replicas
 	ast.Spec.Replicas = &replicas
-	_, err = kruiseClient.AppsV1beta1().StatefulSets(namespace).Update(context.TODO(), ast, metav1.UpdateOptions{})
+	updated, err := kruiseClient.AppsV1beta1().StatefulSets(namespace).Update(ctx, ast, metav1.UpdateOptions{DryRun: biz.DryRun(dryRun)})
 	if err != nil {
+		if dryRun {
+			return "", biz.DryRunError(err)
+		}
 		return "", err
 	}
 
+	if dryRun {
+		return biz.DryRunResult("AdvancedStatefulSet %s in namespace %s would be scaled to %d replicas, resulting in:\n%s",
+			name, namespace, replicas, biz.FormatAdvancedStatefulSetsTable([]appsv1beta1.StatefulSet{*updated})), nil
+	}
+
 	return fmt.Sprintf("Successfully scaled AdvancedStatefulSet %s in namespace %s to %d replicas", name, namespace, replicas), nil
 }
 
 // Scale CloneSet replicas
-func (k *KruiseHandler) scaleCloneSet(namespace, name string, replicas int32) (string, error) {
-	kruiseClient, err := k.getKruiseClient()
-	if err != nil {
-		return "", err
-	}
-
+func (k *KruiseHandler) scaleCloneSet(ctx context.Context, kruiseClient kruiseclientset.Interface, namespace, name string, replicas int32, dryRun bool) (string, error) {
 	// Get the CloneSet
-	cloneSet, err := kruiseClient.AppsV1alpha1().CloneSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	cloneSet, err := kruiseClient.AppsV1alpha1().CloneSets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return "", err
 	}
 
 	// Update the replicas
 	cloneSet.Spec.Replicas = &replicas
-	_, err = kruiseClient.AppsV1alpha1().CloneSets(namespace).Update(context.TODO(), cloneSet, metav1.UpdateOptions{})
+	updated, err := kruiseClient.AppsV1alpha1().CloneSets(namespace).Update(ctx, cloneSet, metav1.UpdateOptions{DryRun: biz.DryRun(dryRun)})
 	if err != nil {
+		if dryRun {
+			return "", biz.DryRunError(err)
+		}
 		return "", err
 	}
 
+	if dryRun {
+		return biz.DryRunResult("CloneSet %s in namespace %s would be scaled to %d replicas, resulting in:\n%s",
+			name, namespace, replicas, biz.FormatCloneSetsTable([]appsv1alpha1.CloneSet{*updated})), nil
+	}
+
 	return fmt.Sprintf("Successfully scaled CloneSet %s in namespace %s to %d replicas", name, namespace, replicas), nil
 }
 
 // Get detailed information about an AdvancedStatefulSet
-func (k *KruiseHandler) describeAdvancedStatefulSetInternal(namespace, name string) (string, error) {
-	kruiseClient, err := k.getKruiseClient()
-	if err != nil {
-		return "", err
-	}
-
+func (k *KruiseHandler) describeAdvancedStatefulSetInternal(ctx context.Context, kruiseClient kruiseclientset.Interface, namespace, name string) (string, error) {
 	// Get the AdvancedStatefulSet
-	ast, err := kruiseClient.AppsV1beta1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	ast, err := kruiseClient.AppsV1beta1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return "", err
 	}
@@ -410,14 +514,9 @@ func (k *KruiseHandler) describeAdvancedStatefulSetInternal(namespace, name stri
 }
 
 // Get detailed information about a CloneSet
-func (k *KruiseHandler) describeCloneSetInternal(namespace, name string) (string, error) {
-	kruiseClient, err := k.getKruiseClient()
-	if err != nil {
-		return "", err
-	}
-
+func (k *KruiseHandler) describeCloneSetInternal(ctx context.Context, kruiseClient kruiseclientset.Interface, namespace, name string) (string, error) {
 	// Get the CloneSet
-	cloneSet, err := kruiseClient.AppsV1alpha1().CloneSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	cloneSet, err := kruiseClient.AppsV1alpha1().CloneSets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return "", err
 	}
@@ -426,19 +525,14 @@ func (k *KruiseHandler) describeCloneSetInternal(namespace, name string) (string
 }
 
 // List AdvancedStatefulSets in a namespace or across all namespaces
-func (k *KruiseHandler) listAdvancedStatefulSetsInternal(namespace string, allNamespaces bool) (string, error) {
-	kruiseClient, err := k.getKruiseClient()
-	if err != nil {
-		return "", err
-	}
-
+func (k *KruiseHandler) listAdvancedStatefulSetsInternal(ctx context.Context, kruiseClient kruiseclientset.Interface, namespace string, allNamespaces bool) (string, error) {
 	var astsList *appsv1beta1.StatefulSetList
 	var listErr error
 
 	if allNamespaces {
-		astsList, listErr = kruiseClient.AppsV1beta1().StatefulSets("").List(context.TODO(), metav1.ListOptions{})
+		astsList, listErr = kruiseClient.AppsV1beta1().StatefulSets("").List(ctx, metav1.ListOptions{})
 	} else {
-		astsList, listErr = kruiseClient.AppsV1beta1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+		astsList, listErr = kruiseClient.AppsV1beta1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
 	}
 
 	if listErr != nil {
@@ -458,19 +552,14 @@ func (k *KruiseHandler) listAdvancedStatefulSetsInternal(namespace string, allNa
 }
 
 // List CloneSets in a namespace or across all namespaces
-func (k *KruiseHandler) listCloneSetsInternal(namespace string, allNamespaces bool) (string, error) {
-	kruiseClient, err := k.getKruiseClient()
-	if err != nil {
-		return "", err
-	}
-
+func (k *KruiseHandler) listCloneSetsInternal(ctx context.Context, kruiseClient kruiseclientset.Interface, namespace string, allNamespaces bool) (string, error) {
 	var cloneSetsList *appsv1alpha1.CloneSetList
 	var listErr error
 
 	if allNamespaces {
-		cloneSetsList, listErr = kruiseClient.AppsV1alpha1().CloneSets("").List(context.TODO(), metav1.ListOptions{})
+		cloneSetsList, listErr = kruiseClient.AppsV1alpha1().CloneSets("").List(ctx, metav1.ListOptions{})
 	} else {
-		cloneSetsList, listErr = kruiseClient.AppsV1alpha1().CloneSets(namespace).List(context.TODO(), metav1.ListOptions{})
+		cloneSetsList, listErr = kruiseClient.AppsV1alpha1().CloneSets(namespace).List(ctx, metav1.ListOptions{})
 	}
 
 	if listErr != nil {
@@ -12,6 +12,7 @@ type KruiseScaleParams struct {
 	Namespace    string `json:"namespace"`
 	ResourceName string `json:"resourceName"`
 	Replicas     string `json:"replicas"`
+	DryRun       bool   `json:"dryRun"`
 }
 
 // KruiseResourceParams defines resource operation parameters
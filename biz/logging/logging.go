@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// loggerKey is the context key under which the call scoped logger is stored
+type loggerKey struct{}
+
+// level is the minimum level of the installed logger, changeable at runtime
+var level slog.LevelVar
+
+// Setup installs the default slog logger. Logs go to stderr, or to path when set,
+// never to stdout which carries the MCP protocol in stdio mode. A log file stays
+// open for the lifetime of the process.
+func Setup(levelName, format, path string) error {
+	if err := SetLevel(levelName); err != nil {
+		return err
+	}
+
+	format = strings.ToLower(format)
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid log format %q, must be text or json", format)
+	}
+
+	var w io.Writer = os.Stderr
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %v", err)
+		}
+		w = file
+	}
+
+	opts := &slog.HandlerOptions{Level: &level}
+	var handler slog.Handler = slog.NewTextHandler(w, opts)
+	if format == "json" {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	// Also routes the standard log package through the handler
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// SetLevel changes the minimum level of the installed logger
+func SetLevel(levelName string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(levelName)); err != nil {
+		return fmt.Errorf("invalid log level %q, must be debug, info, warn or error", levelName)
+	}
+	level.Set(lvl)
+	return nil
+}
+
+// WithLogger returns a copy of ctx carrying the given logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	clientmetrics "k8s.io/client-go/tools/metrics"
+)
+
+var kubeRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "kubernetes_request_duration_seconds",
+	Help:      "Latency of Kubernetes API requests by verb and resource.",
+	Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+}, []string{"verb", "resource"})
+
+// registerKubernetesMetrics hooks the request latency of every client-go client
+func registerKubernetesMetrics() {
+	registry.MustRegister(kubeRequestDuration)
+	clientmetrics.Register(clientmetrics.RegisterOpts{
+		RequestLatency: requestLatency{},
+	})
+}
+
+// requestLatency implements client-go's LatencyMetric
+type requestLatency struct{}
+
+func (requestLatency) Observe(_ context.Context, verb string, u url.URL, latency time.Duration) {
+	kubeRequestDuration.WithLabelValues(verb, resourceFromPath(u.Path)).Observe(latency.Seconds())
+}
+
+// resourceFromPath extracts the resource, and subresource if any, from a Kubernetes API path,
+// e.g. "pods/log" from /api/v1/namespaces/default/pods/nginx/log
+func resourceFromPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		// /api/{version}/...
+		parts = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		// /apis/{group}/{version}/...
+		parts = parts[3:]
+	default:
+		return "other"
+	}
+
+	if len(parts) > 2 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+	switch len(parts) {
+	case 0:
+		return "discovery"
+	case 1, 2:
+		return parts[0]
+	default:
+		return parts[0] + "/" + parts[2]
+	}
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/session"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes all metrics of the server
+const namespace = "mcp_k8s"
+
+var (
+	toolCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_calls_total",
+		Help:      "Number of tool calls by tool.",
+	}, []string{"tool"})
+
+	toolErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_call_errors_total",
+		Help:      "Number of failed tool calls by tool.",
+	}, []string{"tool"})
+
+	toolDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_call_duration_seconds",
+		Help:      "Duration of tool calls by tool.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 15),
+	}, []string{"tool"})
+
+	activeSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Number of open MCP sessions of the SSE and streamable HTTP transports.",
+	})
+
+	cachedClientsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "cached_clients"),
+		"Number of pooled Kubernetes clients by cluster context.",
+		[]string{"context"}, nil,
+	)
+)
+
+// registry holds the metrics served on the metrics endpoint
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		toolCalls,
+		toolErrors,
+		toolDuration,
+		activeSessions,
+		cachedClientsCollector{},
+	)
+	registerKubernetesMetrics()
+
+	session.OnOpen(func(string) {
+		activeSessions.Inc()
+	})
+	session.OnClose(func(string) {
+		activeSessions.Dec()
+	})
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Wrap counts the calls, errors and latency of the named tool
+func Wrap(tool string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, req)
+
+		toolCalls.WithLabelValues(tool).Inc()
+		toolDuration.WithLabelValues(tool).Observe(time.Since(start).Seconds())
+		if err != nil || (result != nil && result.IsError) {
+			toolErrors.WithLabelValues(tool).Inc()
+		}
+		return result, err
+	}
+}
+
+// cachedClientsCollector reports the size of the cluster client pool when scraped
+type cachedClientsCollector struct{}
+
+func (cachedClientsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cachedClientsDesc
+}
+
+func (cachedClientsCollector) Collect(ch chan<- prometheus.Metric) {
+	for contextName, count := range kubeclient.CachedClients() {
+		ch <- prometheus.MustNewConstMetric(cachedClientsDesc, prometheus.GaugeValue, float64(count), contextName)
+	}
+}
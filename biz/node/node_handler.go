@@ -10,6 +10,7 @@ import (
 
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 	"github.com/ThinkInAIXYZ/go-mcp/server"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -33,7 +34,9 @@ func NewNodeHandler() (*NodeHandler, error) {
 		"cordon_node",
 		"Mark Kubernetes Node as Unschedulable",
 		struct {
+			Context  string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			NodeName string `json:"nodeName" description:"Name of the node" required:"true"`
+			DryRun   bool   `json:"dryRun" description:"Only run the update through the API server's validation and admission, without marking the node as unschedulable"`
 		}{},
 	)
 	if err != nil {
@@ -45,7 +48,9 @@ func NewNodeHandler() (*NodeHandler, error) {
 		"uncordon_node",
 		"Mark Kubernetes Node as Schedulable",
 		struct {
+			Context  string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			NodeName string `json:"nodeName" description:"Name of the node" required:"true"`
+			DryRun   bool   `json:"dryRun" description:"Only run the update through the API server's validation and admission, without marking the node as schedulable"`
 		}{},
 	)
 	if err != nil {
@@ -57,6 +62,7 @@ func NewNodeHandler() (*NodeHandler, error) {
 		"describe_node",
 		"Get Detailed Information of a Kubernetes Node",
 		struct {
+			Context  string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			NodeName string `json:"nodeName" description:"Name of the node" required:"true"`
 		}{},
 	)
@@ -69,6 +75,7 @@ func NewNodeHandler() (*NodeHandler, error) {
 		"list_nodes",
 		"List All Kubernetes Nodes",
 		struct {
+			Context       string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			LabelSelector string `json:"labelSelector" description:"Label selector for filtering nodes"`
 		}{},
 	)
@@ -80,6 +87,13 @@ func NewNodeHandler() (*NodeHandler, error) {
 	tools[uncordonNodeTool] = n.uncordonNode
 	tools[describeNodeTool] = n.describe
 	tools[listNodesTool] = n.list
+	biz.DeclareGroup("node", tools)
+
+	// Declare tools that change cluster state
+	biz.DeclareMutating(cordonNodeTool, uncordonNodeTool)
+	biz.DeclareDryRun(cordonNodeTool, uncordonNodeTool)
+	biz.DeclarePreview(cordonNodeTool, n.previewSchedulable(true))
+	biz.DeclarePreview(uncordonNodeTool, n.previewSchedulable(false))
 
 	return n, nil
 }
@@ -93,76 +107,84 @@ func (n *NodeHandler) GetTools() (map[*protocol.Tool]server.ToolHandlerFunc, err
 }
 
 // Handle cordon_node tool
-func (n *NodeHandler) cordonNode(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (n *NodeHandler) cordonNode(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[NodeParams](req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get the latest clientset
-	clientset, err := kubeclient.GetKubeClient()
+	clientset, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Mark node as unschedulable
-	err = n.markNodeAsUnschedulableState(clientset, params.NodeName, true)
+	node, err := n.markNodeAsUnschedulableState(ctx, clientset, params.NodeName, true, params.DryRun)
 	if err != nil {
 		return nil, err
 	}
 
+	text := fmt.Sprintf("Node %s has been marked as unschedulable", params.NodeName)
+	if params.DryRun {
+		text = biz.DryRunResult("node %s would be marked as unschedulable, resulting in:\n%s", params.NodeName, biz.FormatNodesTable([]corev1.Node{*node}))
+	}
 	return &protocol.CallToolResult{
 		Content: []protocol.Content{
 			protocol.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Node %s has been marked as unschedulable", params.NodeName),
+				Text: text,
 			},
 		},
 	}, nil
 }
 
 // Handle uncordon_node tool
-func (n *NodeHandler) uncordonNode(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (n *NodeHandler) uncordonNode(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[NodeParams](req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get the latest clientset
-	clientset, err := kubeclient.GetKubeClient()
+	clientset, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Mark node as schedulable
-	err = n.markNodeAsUnschedulableState(clientset, params.NodeName, false)
+	node, err := n.markNodeAsUnschedulableState(ctx, clientset, params.NodeName, false, params.DryRun)
 	if err != nil {
 		return nil, err
 	}
 
+	text := fmt.Sprintf("Node %s has been marked as schedulable", params.NodeName)
+	if params.DryRun {
+		text = biz.DryRunResult("node %s would be marked as schedulable, resulting in:\n%s", params.NodeName, biz.FormatNodesTable([]corev1.Node{*node}))
+	}
 	return &protocol.CallToolResult{
 		Content: []protocol.Content{
 			protocol.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Node %s has been marked as schedulable", params.NodeName),
+				Text: text,
 			},
 		},
 	}, nil
 }
 
-func (n *NodeHandler) describe(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (n *NodeHandler) describe(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[NodeParams](req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get the latest clientset
-	clientset, err := kubeclient.GetKubeClient()
+	clientset, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	nodeInfo, err := n.describeNode(clientset, params.NodeName)
+	nodeInfo, err := n.describeNode(ctx, clientset, params.NodeName)
 	if err != nil {
 		return nil, err
 	}
@@ -178,9 +200,9 @@ func (n *NodeHandler) describe(_ context.Context, req *protocol.CallToolRequest)
 }
 
 // Handle describe_node tool
-func (n *NodeHandler) describeNode(clientset kubernetes.Interface, nodeName string) (*string, error) {
+func (n *NodeHandler) describeNode(ctx context.Context, clientset kubernetes.Interface, nodeName string) (*string, error) {
 	// Get node information
-	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -191,19 +213,19 @@ func (n *NodeHandler) describeNode(clientset kubernetes.Interface, nodeName stri
 }
 
 // Handle list_nodes tool
-func (n *NodeHandler) list(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (n *NodeHandler) list(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[NodeListParams](req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get the latest clientset
-	clientset, err := kubeclient.GetKubeClient()
+	clientset, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := n.listNodes(clientset, params.LabelSelector)
+	result, err := n.listNodes(ctx, clientset, params.LabelSelector)
 	if err != nil {
 		return nil, err
 	}
@@ -218,14 +240,14 @@ func (n *NodeHandler) list(_ context.Context, req *protocol.CallToolRequest) (*p
 	}, nil
 }
 
-func (n *NodeHandler) listNodes(clientset kubernetes.Interface, labelSelector string) (*string, error) {
+func (n *NodeHandler) listNodes(ctx context.Context, clientset kubernetes.Interface, labelSelector string) (*string, error) {
 	// Get node list
 	listOptions := metav1.ListOptions{}
 	if labelSelector != "" {
 		listOptions.LabelSelector = labelSelector
 	}
 
-	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), listOptions)
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, listOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -236,28 +258,60 @@ func (n *NodeHandler) listNodes(clientset kubernetes.Interface, labelSelector st
 	return &result, nil
 }
 
+// Describe the node cordon_node or uncordon_node would change
+func (n *NodeHandler) previewSchedulable(unschedulable bool) biz.PreviewFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (string, error) {
+		params, err := biz.ParseParams[NodeParams](req)
+		if err != nil {
+			return "", err
+		}
+
+		clientset, err := kubeclient.GetKubeClient(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		node, err := clientset.CoreV1().Nodes().Get(ctx, params.NodeName, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get node %s: %v", params.NodeName, err)
+		}
+
+		action := fmt.Sprintf("Will mark node %s as schedulable", params.NodeName)
+		if unschedulable {
+			action = fmt.Sprintf("Will mark node %s as unschedulable, new Pods will not be scheduled on it", params.NodeName)
+		}
+		if node.Spec.Unschedulable == unschedulable {
+			action += " (it already is, the call will fail)"
+		}
+		return fmt.Sprintf("%s.\n\nCurrent state:\n%s", action, biz.FormatNodesTable([]corev1.Node{*node})), nil
+	}
+}
+
 // Mark node as unschedulable
-func (n *NodeHandler) markNodeAsUnschedulableState(clientset kubernetes.Interface, nodeName string, unscheduleable bool) error {
+func (n *NodeHandler) markNodeAsUnschedulableState(ctx context.Context, clientset kubernetes.Interface, nodeName string, unscheduleable, dryRun bool) (*corev1.Node, error) {
 	// Get the node
-	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Check if the node is already unschedulable
 	if node.Spec.Unschedulable == unscheduleable {
-		return fmt.Errorf("node %s is already marked unscheduable:%v", nodeName, unscheduleable)
+		return nil, fmt.Errorf("node %s is already marked unscheduable:%v", nodeName, unscheduleable)
 	}
 
 	// Make a copy of the node to avoid modifying the original
 	newNode := node.DeepCopy()
 	newNode.Spec.Unschedulable = unscheduleable
 
-	// Update the node
-	_, err = clientset.CoreV1().Nodes().Update(context.TODO(), newNode, metav1.UpdateOptions{})
+	// Update the node, a dry run returns the node as it would have been stored
+	updated, err := clientset.CoreV1().Nodes().Update(ctx, newNode, metav1.UpdateOptions{DryRun: biz.DryRun(dryRun)})
 	if err != nil {
-		return err
+		if dryRun {
+			return nil, biz.DryRunError(err)
+		}
+		return nil, err
 	}
 
-	return nil
+	return updated, nil
 }
@@ -3,6 +3,7 @@ package node
 // NodeParams defines parameters for node operations
 type NodeParams struct {
 	NodeName string `json:"nodeName"`
+	DryRun   bool   `json:"dryRun"`
 }
 
 // NodeListParams defines parameters for listing nodes
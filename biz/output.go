@@ -0,0 +1,52 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+)
+
+// maxOutputBytes bounds the text returned by a tool call, 0 meaning unlimited
+var maxOutputBytes atomic.Int64
+
+// SetMaxOutputBytes sets the maximum size of the text returned by a tool call.
+// A zero or negative limit disables truncation.
+func SetMaxOutputBytes(limit int) {
+	maxOutputBytes.Store(int64(limit))
+}
+
+// withOutputLimit truncates the text content of the tool's results to the configured limit,
+// telling the caller how much was left out
+func withOutputLimit(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		result, err := next(ctx, req)
+		limit := int(maxOutputBytes.Load())
+		if err != nil || result == nil || limit <= 0 {
+			return result, err
+		}
+
+		remaining := limit
+		for i, content := range result.Content {
+			text, ok := content.(protocol.TextContent)
+			if !ok {
+				continue
+			}
+			if len(text.Text) > remaining {
+				// Cut at a rune boundary so the kept text stays valid UTF-8
+				cut := remaining
+				for cut > 0 && !utf8.RuneStart(text.Text[cut]) {
+					cut--
+				}
+				omitted := len(text.Text) - cut
+				text.Text = fmt.Sprintf("%s\n... output truncated, %d bytes omitted (limit %d bytes)", text.Text[:cut], omitted, limit)
+				result.Content[i] = text
+			}
+			remaining = max(remaining-len(text.Text), 0)
+		}
+		return result, nil
+	}
+}
@@ -18,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
 )
 
@@ -39,6 +40,7 @@ func NewPodHandler() (*PodHandler, error) {
 		"get_pod_logs",
 		"Get Pod Logs",
 		struct {
+			Context   string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace string `json:"namespace" description:"Namespace of the Pod" required:"true"`
 			PodName   string `json:"podName" description:"Name of the Pod" required:"true"`
 			Container string `json:"container" description:"Name of the container to get logs from"`
@@ -52,9 +54,11 @@ func NewPodHandler() (*PodHandler, error) {
 		"delete_pod",
 		"Delete Pod",
 		struct {
+			Context   string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace string `json:"namespace" description:"Namespace of the resource, default is 'default'"`
 			PodName   string `json:"podName" description:"Name of the Pod to delete" required:"true"`
 			Force     bool   `json:"force" description:"Force delete (only applicable to Pod)"`
+			DryRun    bool   `json:"dryRun" description:"Only run the request through the API server's validation and admission, without deleting the Pod"`
 		}{},
 	)
 	if err != nil {
@@ -66,7 +70,7 @@ func NewPodHandler() (*PodHandler, error) {
 		"exec_command_in_pod",
 		"Execute Command in Pod",
 		struct {
-			Context   string `json:"context" description:"Kubernetes cluster context name" required:"true"`
+			Context   string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace string `json:"namespace" description:"Namespace of the Pod" required:"true"`
 			PodName   string `json:"podName" description:"Name of the Pod" required:"true"`
 			Command   string `json:"command" description:"Command to execute" required:"true"`
@@ -81,6 +85,7 @@ func NewPodHandler() (*PodHandler, error) {
 		"describe_pod",
 		"Get Detailed Pod Information",
 		struct {
+			Context   string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace string `json:"namespace" description:"Namespace of the Pod, default is 'default'"`
 			PodName   string `json:"podName" description:"Name of the Pod" required:"true"`
 		}{},
@@ -94,6 +99,7 @@ func NewPodHandler() (*PodHandler, error) {
 		"list_pods",
 		"List Pods in a Namespace",
 		struct {
+			Context       string `json:"context" description:"Kubernetes cluster context name, defaults to the current context"`
 			Namespace     string `json:"namespace" description:"Namespace of Pods, default is 'default'"`
 			LabelSelector string `json:"labelSelector" description:"Label selector for filtering Pods"`
 			AllNamespaces bool   `json:"allNamespaces" description:"List Pods in all namespaces"`
@@ -108,6 +114,17 @@ func NewPodHandler() (*PodHandler, error) {
 	tools[execCommandTool] = p.execCommand
 	tools[describePodTool] = p.describePod
 	tools[listPodsTool] = p.listPods
+	biz.DeclareGroup("pod", tools)
+
+	// Declare tools that change cluster state
+	biz.DeclareMutating(deletePodTool, execCommandTool)
+	biz.DeclareStreaming(getPodLogsTool, execCommandTool)
+	biz.DeclareDryRun(deletePodTool)
+	biz.DeclarePreview(deletePodTool, p.previewDelete)
+	biz.DeclarePreview(execCommandTool, p.previewExecCommand)
+
+	// Declare the namespace targeted when the namespace argument is omitted
+	biz.DeclareDefaultNamespace("default", describePodTool, listPodsTool)
 	return p, nil
 }
 
@@ -120,17 +137,17 @@ func (p *PodHandler) GetTools() (map[*protocol.Tool]server.ToolHandlerFunc, erro
 }
 
 // Handle get_pod_logs tool
-func (p *PodHandler) getLogs(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (p *PodHandler) getLogs(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[podParams](req)
 	if err != nil {
 		return nil, err
 	}
-	clientset, err := kubeclient.GetKubeClient()
+	clientset, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 	// Get Pod logs
-	logs, err := p.getPodLogs(clientset, params.Namespace, params.PodName, params.Container)
+	logs, err := p.getPodLogs(ctx, clientset, params.Namespace, params.PodName, params.Container)
 	if err != nil {
 		return nil, err
 	}
@@ -146,10 +163,10 @@ func (p *PodHandler) getLogs(_ context.Context, req *protocol.CallToolRequest) (
 }
 
 // Get pod logs
-func (p *PodHandler) getPodLogs(clientset kubernetes.Interface, namespace, podName, containerName string) (string, error) {
+func (p *PodHandler) getPodLogs(ctx context.Context, clientset kubernetes.Interface, namespace, podName, containerName string) (string, error) {
 	// If container name is not specified, try to get Pod info to determine the container
 	if containerName == "" {
-		pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 		if err != nil {
 			return "", fmt.Errorf("error getting pod info: %v", err)
 		}
@@ -166,7 +183,7 @@ func (p *PodHandler) getPodLogs(clientset kubernetes.Interface, namespace, podNa
 	logsReq := clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
 		Container: containerName,
 	})
-	podLogs, err := logsReq.Stream(context.TODO())
+	podLogs, err := logsReq.Stream(ctx)
 	if err != nil {
 		return "", fmt.Errorf("error in opening stream: %v", err)
 	}
@@ -182,52 +199,112 @@ func (p *PodHandler) getPodLogs(clientset kubernetes.Interface, namespace, podNa
 }
 
 // Delete pod
-func (p *PodHandler) delete(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (p *PodHandler) delete(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[deletePodParams](req)
 	if err != nil {
 		return nil, err
 	}
 
-	clientset, err := kubeclient.GetKubeClient()
+	clientset, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	err = p.deletePod(clientset, params.Namespace, params.PodName, params.Force)
+	err = p.deletePod(ctx, clientset, params.Namespace, params.PodName, params.Force, params.DryRun)
+	if err != nil {
+		if params.DryRun {
+			return nil, biz.DryRunError(err)
+		}
+		return nil, err
+	}
+
+	text := fmt.Sprintf("Pod %s in namespace %s successfully deleted", params.PodName, params.Namespace)
+	if params.DryRun {
+		text = biz.DryRunResult("the API server accepted the deletion of Pod %s in namespace %s", params.PodName, params.Namespace)
+		if params.Force {
+			text += ", without grace period"
+		}
+	}
 	return &protocol.CallToolResult{
 		Content: []protocol.Content{
 			protocol.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Pod %s in namespace %s successfully deleted", params.PodName, params.Namespace),
+				Text: text,
 			},
 		},
 	}, nil
 }
 
-func (p *PodHandler) deletePod(clientset kubernetes.Interface, namespace, podName string, force bool) error {
-	deleteOptions := metav1.DeleteOptions{}
+func (p *PodHandler) deletePod(ctx context.Context, clientset kubernetes.Interface, namespace, podName string, force, dryRun bool) error {
+	deleteOptions := metav1.DeleteOptions{DryRun: biz.DryRun(dryRun)}
 	if force {
 		gracePeriod := int64(0)
 		deleteOptions.GracePeriodSeconds = &gracePeriod
 	}
-	return clientset.CoreV1().Pods(namespace).Delete(context.TODO(), podName, deleteOptions)
+	return clientset.CoreV1().Pods(namespace).Delete(ctx, podName, deleteOptions)
+}
+
+// Describe the Pod delete_pod would delete
+func (p *PodHandler) previewDelete(ctx context.Context, req *protocol.CallToolRequest) (string, error) {
+	params, err := biz.ParseParams[deletePodParams](req)
+	if err != nil {
+		return "", err
+	}
+
+	action := fmt.Sprintf("Will delete Pod %s in namespace %s", params.PodName, params.Namespace)
+	if params.Force {
+		action += " immediately, without grace period (force)"
+	}
+	return p.previewPod(ctx, action, params.Namespace, params.PodName)
+}
+
+// Describe the command exec_command_in_pod would run
+func (p *PodHandler) previewExecCommand(ctx context.Context, req *protocol.CallToolRequest) (string, error) {
+	params, err := biz.ParseParams[execCommandParams](req)
+	if err != nil {
+		return "", err
+	}
+
+	action := fmt.Sprintf("Will run /bin/sh -c %q in Pod %s in namespace %s", params.Command, params.PodName, params.Namespace)
+	return p.previewPod(ctx, action, params.Namespace, params.PodName)
+}
+
+// Append the current state of the Pod to the description of an action on it
+func (p *PodHandler) previewPod(ctx context.Context, action, namespace, podName string) (string, error) {
+	clientset, err := kubeclient.GetKubeClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get Pod %s in namespace %s: %v", podName, namespace, err)
+	}
+
+	return fmt.Sprintf("%s.\n\nCurrent state:\n%s", action, biz.FormatPodsTable([]corev1.Pod{*pod})), nil
 }
 
 // Handle exec_command_in_pod tool
-func (p *PodHandler) execCommand(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (p *PodHandler) execCommand(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[execCommandParams](req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get clientset
-	kubeClient, err := kubeclient.GetKubeClient()
+	kubeClient, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	// Get RESTClient config directly from clientset package
+	restConfig, err := kubeclient.GetRESTConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get REST config: %v", err)
+	}
+
 	// Execute command
-	output, err := p.execCommandInPod(kubeClient, params.Namespace, params.PodName, params.Command)
+	output, err := p.execCommandInPod(ctx, kubeClient, restConfig, params.Namespace, params.PodName, params.Command)
 	if err != nil {
 		return nil, err
 	}
@@ -243,16 +320,10 @@ func (p *PodHandler) execCommand(_ context.Context, req *protocol.CallToolReques
 }
 
 // Execute command in specified Pod
-func (p *PodHandler) execCommandInPod(clientsetInterface kubernetes.Interface, namespace, podName, command string) (string, error) {
+func (p *PodHandler) execCommandInPod(ctx context.Context, clientsetInterface kubernetes.Interface, restConfig *rest.Config, namespace, podName, command string) (string, error) {
 	// Create buffers to capture command output
 	var stdout, stderr bytes.Buffer
 
-	// Get RESTClient config directly from clientset package
-	restConfig, err := kubeclient.GetRESTConfig()
-	if err != nil {
-		return "", fmt.Errorf("failed to get REST config: %v", err)
-	}
-
 	// Build API request
 	req := clientsetInterface.CoreV1().RESTClient().Post().
 		Resource("pods").
@@ -276,7 +347,7 @@ func (p *PodHandler) execCommandInPod(clientsetInterface kubernetes.Interface, n
 	}
 
 	// Execute command and capture output
-	err = exec.StreamWithContext(context.TODO(), remotecommand.StreamOptions{
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
 		Stdout: &stdout,
 		Stderr: &stderr,
 	})
@@ -297,7 +368,7 @@ func (p *PodHandler) execCommandInPod(clientsetInterface kubernetes.Interface, n
 }
 
 // Handle describe_pod tool
-func (p *PodHandler) describePod(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (p *PodHandler) describePod(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	params, err := biz.ParseParams[describePodParams](req)
 	if err != nil {
 		return nil, err
@@ -308,8 +379,14 @@ func (p *PodHandler) describePod(_ context.Context, req *protocol.CallToolReques
 		params.Namespace = "default"
 	}
 
+	// Get the latest clientset
+	clientset, err := kubeclient.GetKubeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get Pod detailed information
-	podInfo, err := p.describePodInternal(params.Namespace, params.PodName)
+	podInfo, err := p.describePodInternal(ctx, clientset, params.Namespace, params.PodName)
 	if err != nil {
 		return nil, err
 	}
@@ -325,14 +402,8 @@ func (p *PodHandler) describePod(_ context.Context, req *protocol.CallToolReques
 }
 
 // Get detailed Pod information
-func (p *PodHandler) describePodInternal(namespace, podName string) (string, error) {
-	// Get the latest clientset
-	clientset, err := kubeclient.GetKubeClient()
-	if err != nil {
-		return "", err
-	}
-
-	pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+func (p *PodHandler) describePodInternal(ctx context.Context, clientset kubernetes.Interface, namespace, podName string) (string, error) {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to get Pod %s info: %v", podName, err)
 	}
@@ -405,7 +476,7 @@ func (p *PodHandler) describePodInternal(namespace, podName string) (string, err
 	}
 
 	// Events
-	events, err := clientset.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{
+	events, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.namespace=%s,involvedObject.kind=Pod",
 			podName, namespace),
 	})
@@ -425,14 +496,14 @@ func (p *PodHandler) describePodInternal(namespace, podName string) (string, err
 }
 
 // Handle list_pods tool
-func (p *PodHandler) listPods(_ context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+func (p *PodHandler) listPods(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
 	var params listPodsParams
 
 	if err := json.Unmarshal(req.RawArguments, &params); err != nil {
 		return nil, err
 	}
 
-	clientset, err := kubeclient.GetKubeClient()
+	clientset, err := kubeclient.GetKubeClient(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -453,10 +524,10 @@ func (p *PodHandler) listPods(_ context.Context, req *protocol.CallToolRequest)
 
 	if params.AllNamespaces {
 		// Get Pods from all namespaces
-		pods, err = clientset.CoreV1().Pods("").List(context.TODO(), listOptions)
+		pods, err = clientset.CoreV1().Pods("").List(ctx, listOptions)
 	} else {
 		// Get Pods from specified namespace
-		pods, err = clientset.CoreV1().Pods(namespace).List(context.TODO(), listOptions)
+		pods, err = clientset.CoreV1().Pods(namespace).List(ctx, listOptions)
 	}
 
 	if err != nil {
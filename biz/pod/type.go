@@ -7,7 +7,6 @@ type podParams struct {
 }
 
 type execCommandParams struct {
-	Context   string `json:"context"`
 	Namespace string `json:"namespace"`
 	PodName   string `json:"podName"`
 	Command   string `json:"command"`
@@ -28,4 +27,5 @@ type deletePodParams struct {
 	Namespace string `json:"namespace"`
 	PodName   string `json:"podName"`
 	Force     bool   `json:"force"`
+	DryRun    bool   `json:"dryRun"`
 }
@@ -0,0 +1,69 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz/auth"
+	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/policy"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+)
+
+// withNamespaceGuard refuses calls whose namespace argument is outside the permitted
+// namespaces. Namespaces a handler defaults to are refused, and listings across all
+// namespaces filtered, by the guard on the Kubernetes client.
+func withNamespaceGuard(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		if params, err := ParseParams[targetParams](req); err == nil && params.Namespace != "" && !params.AllNamespaces {
+			if err := policy.CheckNamespace(params.Namespace); err != nil {
+				return nil, err
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// withPolicy evaluates the policy rules against every call of the named tool before it runs
+func withPolicy(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		if err := policy.Authorize(policyRequest(ctx, name, req)); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// policyRequest describes the caller, target and arguments of a tool call for the policy engine
+func policyRequest(ctx context.Context, name string, req *protocol.CallToolRequest) *policy.Request {
+	r := &policy.Request{
+		Tool:      name,
+		Principal: auth.PrincipalName(ctx),
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		r.Groups = principal.Groups
+	}
+	if contextName, err := kubeclient.ResolveContext(ctx); err == nil {
+		r.Context = contextName
+	}
+	if params, err := ParseParams[targetParams](req); err == nil {
+		r.Namespace = targetNamespace(name, params)
+	}
+	// Arguments that do not parse as an object leave conditions on arguments unmet
+	_ = json.Unmarshal(req.RawArguments, &r.Arguments)
+	return r
+}
+
+// targetNamespace returns the namespace a call of the named tool targets, resolving an
+// omitted namespace the way the handler does
+func targetNamespace(name string, params targetParams) string {
+	switch {
+	case params.AllNamespaces:
+		return policy.AllNamespaces
+	case params.Namespace != "":
+		return params.Namespace
+	}
+	return defaultNamespace(name)
+}
@@ -0,0 +1,197 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrPolicyViolation is wrapped by every error refusing a call because of the server's policy
+var ErrPolicyViolation = errors.New("policy violation")
+
+// namespaces holds the namespace restrictions, checked by every tool call and Kubernetes request
+var namespaces = struct {
+	sync.RWMutex
+	allow []string
+	deny  []string
+}{}
+
+// SetNamespaceRestrictions sets the glob patterns of the namespaces tools may target.
+// When allow is empty every namespace not matched by deny is permitted, deny always wins.
+func SetNamespaceRestrictions(allow, deny []string) error {
+	for _, pattern := range append(append([]string{}, allow...), deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q: %v", pattern, err)
+		}
+	}
+
+	namespaces.Lock()
+	defer namespaces.Unlock()
+	namespaces.allow = allow
+	namespaces.deny = deny
+	return nil
+}
+
+// Restricted reports whether any namespace restriction is configured
+func Restricted() bool {
+	namespaces.RLock()
+	defer namespaces.RUnlock()
+	return len(namespaces.allow) > 0 || len(namespaces.deny) > 0
+}
+
+// CheckNamespace returns a policy error if the namespace may not be targeted
+func CheckNamespace(namespace string) error {
+	namespaces.RLock()
+	defer namespaces.RUnlock()
+
+	if matchAny(namespaces.deny, namespace) {
+		return fmt.Errorf("%w: namespace %q is denied by the server's namespace restrictions", ErrPolicyViolation, namespace)
+	}
+	if len(namespaces.allow) > 0 && !matchAny(namespaces.allow, namespace) {
+		return fmt.Errorf("%w: namespace %q is not in the server's namespace allowlist", ErrPolicyViolation, namespace)
+	}
+	return nil
+}
+
+// NamespaceAllowed reports whether the namespace may be targeted
+func NamespaceAllowed(namespace string) bool {
+	return CheckNamespace(namespace) == nil
+}
+
+// WrapTransport refuses Kubernetes API requests to objects in namespaces that may not be
+// targeted, catching namespaces that handlers default to such as "default", and removes
+// the objects of such namespaces from listings across all namespaces.
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if namespace, ok := requestNamespace(req.URL.Path); ok {
+			if err := CheckNamespace(namespace); err != nil {
+				return nil, err
+			}
+			return rt.RoundTrip(req)
+		}
+		if req.Method != http.MethodGet || !listsCollection(req.URL.Path) || !Restricted() {
+			return rt.RoundTrip(req)
+		}
+		if req.URL.Query().Get("watch") == "true" {
+			return nil, fmt.Errorf("%w: watching across all namespaces is not possible with the server's namespace restrictions", ErrPolicyViolation)
+		}
+
+		// Ask for JSON so that the items can be filtered, whatever the client's content type
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept", "application/json")
+		resp, err := rt.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return resp, err
+		}
+		if err := filterListResponse(resp); err != nil {
+			return nil, fmt.Errorf("unable to filter the listing by the server's namespace restrictions: %v", err)
+		}
+		return resp, nil
+	})
+}
+
+// filterListResponse removes the objects living in namespaces that may not be targeted
+// from the JSON list in the response body. Cluster-scoped objects are kept.
+func filterListResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		return fmt.Errorf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	var list map[string]json.RawMessage
+	if err := json.Unmarshal(body, &list); err != nil {
+		return err
+	}
+	if raw, ok := list["items"]; ok {
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		permitted := make([]json.RawMessage, 0, len(items))
+		for _, item := range items {
+			var object struct {
+				Metadata struct {
+					Namespace string `json:"namespace"`
+				} `json:"metadata"`
+			}
+			if err := json.Unmarshal(item, &object); err != nil {
+				return err
+			}
+			if object.Metadata.Namespace == "" || NamespaceAllowed(object.Metadata.Namespace) {
+				permitted = append(permitted, item)
+			}
+		}
+		if list["items"], err = json.Marshal(permitted); err != nil {
+			return err
+		}
+		if body, err = json.Marshal(list); err != nil {
+			return err
+		}
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// requestNamespace extracts the namespace from a Kubernetes API path,
+// e.g. "shop" from /api/v1/namespaces/shop/pods/cart
+func requestNamespace(urlPath string) (string, bool) {
+	parts, ok := resourcePath(urlPath)
+	if ok && len(parts) >= 2 && parts[0] == "namespaces" {
+		return parts[1], true
+	}
+	return "", false
+}
+
+// listsCollection reports whether a Kubernetes API path names a collection outside any
+// namespace, e.g. /api/v1/pods listing the Pods of all namespaces
+func listsCollection(urlPath string) bool {
+	parts, ok := resourcePath(urlPath)
+	return ok && len(parts) == 1
+}
+
+// resourcePath returns the parts of a Kubernetes API path following the API group
+// and version, e.g. ["namespaces", "shop", "pods"] for /api/v1/namespaces/shop/pods
+func resourcePath(urlPath string) ([]string, bool) {
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		// /api/{version}/...
+		return parts[2:], true
+	case len(parts) >= 4 && parts[0] == "apis":
+		// /apis/{group}/{version}/...
+		return parts[3:], true
+	}
+	return nil, false
+}
+
+// matchAny reports whether any of the glob patterns matches the namespace
+func matchAny(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,296 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRequestNamespace(t *testing.T) {
+	tests := []struct {
+		path       string
+		namespace  string
+		namespaced bool
+		collection bool
+	}{
+		{path: "/api/v1/namespaces/shop/pods", namespace: "shop", namespaced: true},
+		{path: "/api/v1/namespaces/shop/pods/cart/log", namespace: "shop", namespaced: true},
+		{path: "/api/v1/namespaces/shop/configmaps/settings", namespace: "shop", namespaced: true},
+		{path: "/apis/apps/v1/namespaces/shop/deployments", namespace: "shop", namespaced: true},
+		{path: "/apis/apps.kruise.io/v1alpha1/namespaces/shop/clonesets/cart/scale", namespace: "shop", namespaced: true},
+		{path: "/apis/apps.kruise.io/v1beta1/namespaces/kube-system/statefulsets", namespace: "kube-system", namespaced: true},
+		{path: "/api/v1/pods", collection: true},
+		{path: "/api/v1/configmaps/", collection: true},
+		{path: "/apis/apps.kruise.io/v1alpha1/clonesets", collection: true},
+		{path: "/apis/apps.kruise.io/v1beta1/statefulsets", collection: true},
+		{path: "/api/v1/nodes", collection: true},
+		{path: "/api/v1/namespaces", collection: true},
+		{path: "/api/v1/nodes/worker-1"},
+		{path: "/api/v1/namespaces/shop", namespace: "shop", namespaced: true},
+		{path: "/api/v1"},
+		{path: "/apis/apps.kruise.io/v1alpha1"},
+		{path: "/apis"},
+		{path: "/version"},
+		{path: "/healthz/namespaces/shop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			namespace, namespaced := requestNamespace(tt.path)
+			if namespace != tt.namespace || namespaced != tt.namespaced {
+				t.Errorf("requestNamespace() = %q, %v, want %q, %v", namespace, namespaced, tt.namespace, tt.namespaced)
+			}
+			if collection := listsCollection(tt.path); collection != tt.collection {
+				t.Errorf("listsCollection() = %v, want %v", collection, tt.collection)
+			}
+		})
+	}
+}
+
+func TestWrapTransport(t *testing.T) {
+	const podList = `{"kind":"PodList","apiVersion":"v1","metadata":{"resourceVersion":"7"},"items":[` +
+		`{"metadata":{"name":"cart","namespace":"shop"}},` +
+		`{"metadata":{"name":"coredns","namespace":"kube-system"}},` +
+		`{"metadata":{"name":"proxy","namespace":"kube-public"}},` +
+		`{"metadata":{"name":"checkout","namespace":"shop"}}]}`
+	const nodeList = `{"kind":"NodeList","apiVersion":"v1","metadata":{},"items":[` +
+		`{"metadata":{"name":"worker-1"}},{"metadata":{"name":"worker-2"}}]}`
+	const notFound = `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`
+
+	tests := []struct {
+		name     string
+		restrict bool
+		method   string
+		url      string
+		status   int
+		body     string
+		// wantErr is part of the expected policy error, empty when the request goes through
+		wantErr string
+		// wantItems are the names of the listed objects after filtering, nil to expect
+		// the response body unchanged
+		wantItems []string
+	}{
+		{
+			name:     "permitted namespace",
+			restrict: true,
+			url:      "/api/v1/namespaces/shop/pods/cart",
+			body:     `{"metadata":{"name":"cart","namespace":"shop"}}`,
+		},
+		{
+			name:     "denied namespace",
+			restrict: true,
+			url:      "/api/v1/namespaces/kube-system/pods",
+			wantErr:  `namespace "kube-system" is denied`,
+		},
+		{
+			name:     "denied namespace in a group",
+			restrict: true,
+			method:   http.MethodDelete,
+			url:      "/apis/apps/v1/namespaces/kube-system/deployments/coredns",
+			wantErr:  `namespace "kube-system" is denied`,
+		},
+		{
+			name:     "denied namespace of a kruise resource",
+			restrict: true,
+			method:   http.MethodPatch,
+			url:      "/apis/apps.kruise.io/v1alpha1/namespaces/kube-public/clonesets/proxy/scale",
+			wantErr:  `namespace "kube-public" is denied`,
+		},
+		{
+			name:     "watch in a denied namespace",
+			restrict: true,
+			url:      "/api/v1/namespaces/kube-system/pods?watch=true",
+			wantErr:  `namespace "kube-system" is denied`,
+		},
+		{
+			name:     "watch in a permitted namespace",
+			restrict: true,
+			url:      "/api/v1/namespaces/shop/pods?watch=true",
+			body:     `{"type":"ADDED","object":{"metadata":{"name":"cart","namespace":"shop"}}}`,
+		},
+		{
+			name:      "core list across all namespaces",
+			restrict:  true,
+			url:       "/api/v1/pods",
+			body:      podList,
+			wantItems: []string{"cart", "checkout"},
+		},
+		{
+			name:      "kruise list across all namespaces",
+			restrict:  true,
+			url:       "/apis/apps.kruise.io/v1alpha1/clonesets?labelSelector=app%3Dcart",
+			body:      strings.ReplaceAll(podList, "PodList", "CloneSetList"),
+			wantItems: []string{"cart", "checkout"},
+		},
+		{
+			name:     "watch across all namespaces",
+			restrict: true,
+			url:      "/apis/apps.kruise.io/v1beta1/statefulsets?watch=true",
+			wantErr:  "watching across all namespaces is not possible",
+		},
+		{
+			name:      "cluster-scoped list",
+			restrict:  true,
+			url:       "/api/v1/nodes",
+			body:      nodeList,
+			wantItems: []string{"worker-1", "worker-2"},
+		},
+		{
+			name:     "cluster-scoped object",
+			restrict: true,
+			method:   http.MethodPatch,
+			url:      "/api/v1/nodes/worker-1",
+			body:     `{"metadata":{"name":"worker-1"}}`,
+		},
+		{
+			name:     "non-200 list response is passed through",
+			restrict: true,
+			url:      "/apis/apps.kruise.io/v1beta1/statefulsets",
+			status:   http.StatusNotFound,
+			body:     notFound,
+		},
+		{
+			name:     "forbidden list response is passed through",
+			restrict: true,
+			url:      "/api/v1/configmaps",
+			status:   http.StatusForbidden,
+			body:     `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403}`,
+		},
+		{
+			name: "list without restrictions is passed through",
+			url:  "/api/v1/pods",
+			body: podList,
+		},
+		{
+			name: "watch without restrictions",
+			url:  "/api/v1/pods?watch=true",
+			body: `{"type":"ADDED","object":{"metadata":{"name":"coredns","namespace":"kube-system"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deny := []string(nil)
+			if tt.restrict {
+				deny = []string{"kube-*"}
+			}
+			if err := SetNamespaceRestrictions(nil, deny); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { SetNamespaceRestrictions(nil, nil) })
+
+			status := tt.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			called := false
+			transport := WrapTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				called = true
+				if tt.wantItems != nil && req.Header.Get("Accept") != "application/json" {
+					t.Errorf("filtered list requested with Accept %q, want application/json", req.Header.Get("Accept"))
+				}
+				return &http.Response{
+					StatusCode:    status,
+					Header:        http.Header{"Content-Type": {"application/json"}, "Content-Length": {strconv.Itoa(len(tt.body))}},
+					Body:          io.NopCloser(strings.NewReader(tt.body)),
+					ContentLength: int64(len(tt.body)),
+					Request:       req,
+				}, nil
+			}))
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, "https://kubernetes.test"+tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/vnd.kubernetes.protobuf, application/json")
+
+			resp, err := transport.RoundTrip(req)
+			if tt.wantErr != "" {
+				if err == nil || !errors.Is(err, ErrPolicyViolation) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RoundTrip() error = %v, want a policy violation containing %q", err, tt.wantErr)
+				}
+				if called {
+					t.Error("refused request was sent to the API server")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RoundTrip() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, status)
+			}
+			if resp.ContentLength != int64(len(body)) || resp.Header.Get("Content-Length") != strconv.Itoa(len(body)) {
+				t.Errorf("Content-Length = %d (header %q), want %d", resp.ContentLength, resp.Header.Get("Content-Length"), len(body))
+			}
+
+			if tt.wantItems == nil {
+				if string(body) != tt.body {
+					t.Errorf("body = %s, want it unchanged: %s", body, tt.body)
+				}
+				return
+			}
+
+			var list struct {
+				Kind     string         `json:"kind"`
+				Metadata map[string]any `json:"metadata"`
+				Items    []struct {
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				} `json:"items"`
+			}
+			if err := json.Unmarshal(body, &list); err != nil {
+				t.Fatalf("filtered body is not a JSON list: %v: %s", err, body)
+			}
+			var names []string
+			for _, item := range list.Items {
+				names = append(names, item.Metadata.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantItems, ",") {
+				t.Errorf("items = %v, want %v", names, tt.wantItems)
+			}
+			if list.Kind == "" || list.Metadata == nil {
+				t.Errorf("filtered list lost its kind or metadata: %s", body)
+			}
+		})
+	}
+}
+
+func TestWrapTransportRejectsUnfilterableList(t *testing.T) {
+	if err := SetNamespaceRestrictions(nil, []string{"kube-*"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetNamespaceRestrictions(nil, nil) })
+
+	transport := WrapTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/vnd.kubernetes.protobuf"}},
+			Body:       io.NopCloser(strings.NewReader("k8s\x00")),
+			Request:    req,
+		}, nil
+	}))
+	req, err := http.NewRequest(http.MethodGet, "https://kubernetes.test/api/v1/pods", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := transport.RoundTrip(req); err == nil {
+		resp.Body.Close()
+		t.Fatal("RoundTrip() passed an unfilterable listing through, want an error")
+	}
+}
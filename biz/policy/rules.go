@@ -0,0 +1,319 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"sync/atomic"
+)
+
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+
+	// AllNamespaces is the namespace of requests listing across all namespaces
+	AllNamespaces = "*"
+)
+
+// Config is the declarative tool authorization policy. Rules are evaluated in order and
+// the first rule matching a call decides; calls matched by no rule get the default effect.
+type Config struct {
+	Default string `json:"default"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Rule allows or denies the calls it matches. Every non-empty selector must match, lists
+// matching when any of their glob patterns does, and every condition must hold.
+type Rule struct {
+	Name       string      `json:"name"`
+	Effect     string      `json:"effect"`
+	Tools      []string    `json:"tools"`
+	Principals []string    `json:"principals"`
+	Groups     []string    `json:"groups"`
+	Namespaces []string    `json:"namespaces"`
+	Contexts   []string    `json:"contexts"`
+	When       []Condition `json:"when"`
+	Message    string      `json:"message"`
+}
+
+// Condition compares a tool argument with a value, e.g. replicas gt 50
+type Condition struct {
+	Argument string `json:"argument"`
+	Op       string `json:"op"`
+	Value    any    `json:"value"`
+}
+
+// Request describes a tool call to authorize
+type Request struct {
+	Tool      string
+	Principal string
+	Groups    []string
+	// Namespace is the namespace the call targets, AllNamespaces for listings across
+	// all namespaces and empty for cluster-scoped tools
+	Namespace string
+	Context   string
+	Arguments map[string]any
+}
+
+// Engine evaluates a validated policy
+type Engine struct {
+	defaultEffect string
+	rules         []Rule
+}
+
+// engine is the active policy, nil when no rule is configured
+var engine atomic.Pointer[Engine]
+
+// NewEngine validates the policy and returns its engine, nil when the policy is empty
+func NewEngine(config Config) (*Engine, error) {
+	var errs []error
+	defaultEffect := config.Default
+	switch defaultEffect {
+	case "":
+		defaultEffect = EffectAllow
+	case EffectAllow, EffectDeny:
+	default:
+		errs = append(errs, fmt.Errorf("default: effect must be allow or deny, got %q", config.Default))
+	}
+
+	for i, rule := range config.Rules {
+		if err := rule.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %v", rule.label(i), err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if len(config.Rules) == 0 && defaultEffect == EffectAllow {
+		return nil, nil
+	}
+	rules := make([]Rule, len(config.Rules))
+	for i, rule := range config.Rules {
+		rule.Name = rule.label(i)
+		rules[i] = rule
+	}
+	return &Engine{defaultEffect: defaultEffect, rules: rules}, nil
+}
+
+// SetPolicy validates and installs the policy checked before every tool call
+func SetPolicy(config Config) error {
+	e, err := NewEngine(config)
+	if err != nil {
+		return err
+	}
+	engine.Store(e)
+	return nil
+}
+
+// Authorize returns a policy error if the active policy denies the call
+func Authorize(req *Request) error {
+	e := engine.Load()
+	if e == nil {
+		return nil
+	}
+	return e.Authorize(req)
+}
+
+// Authorize returns a policy error if the policy denies the call
+func (e *Engine) Authorize(req *Request) error {
+	for _, rule := range e.rules {
+		if !rule.matches(req) {
+			continue
+		}
+		if rule.Effect == EffectAllow {
+			return nil
+		}
+		if rule.Message != "" {
+			return fmt.Errorf("%w: %s (rule %s)", ErrPolicyViolation, rule.Message, rule.Name)
+		}
+		return fmt.Errorf("%w: rule %s denies %s", ErrPolicyViolation, rule.Name, req.Tool)
+	}
+
+	if e.defaultEffect == EffectDeny {
+		return fmt.Errorf("%w: no policy rule allows %s", ErrPolicyViolation, req.Tool)
+	}
+	return nil
+}
+
+// label names the rule in errors, falling back to its position
+func (r *Rule) label(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return "#" + strconv.Itoa(i+1)
+}
+
+// validate checks the effect, patterns and conditions of the rule
+func (r *Rule) validate() error {
+	if r.Effect != EffectAllow && r.Effect != EffectDeny {
+		return fmt.Errorf("effect must be allow or deny, got %q", r.Effect)
+	}
+	for _, patterns := range [][]string{r.Tools, r.Principals, r.Groups, r.Namespaces, r.Contexts} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+			}
+		}
+	}
+	for _, c := range r.When {
+		if err := c.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to the call
+func (r *Rule) matches(req *Request) bool {
+	if !selects(r.Tools, req.Tool) || !selects(r.Principals, req.Principal) ||
+		!r.selectsNamespace(req.Namespace) || !selects(r.Contexts, req.Context) {
+		return false
+	}
+	if len(r.Groups) > 0 && !anyGroup(r.Groups, req.Groups) {
+		return false
+	}
+	for _, c := range r.When {
+		if !c.holds(req.Arguments) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectsNamespace reports whether the rule's namespace selector matches the namespace.
+// Cluster-scoped calls match no namespace selector. A listing across all namespaces
+// reads every namespace a deny rule names, but is only allowed by rules whose patterns
+// match all namespaces, such as "*".
+func (r *Rule) selectsNamespace(namespace string) bool {
+	switch {
+	case len(r.Namespaces) == 0:
+		return true
+	case namespace == "":
+		return false
+	case namespace == AllNamespaces && r.Effect == EffectDeny:
+		return true
+	}
+	return matchAny(r.Namespaces, namespace)
+}
+
+// selects reports whether an empty selector or any of its patterns matches the value
+func selects(patterns []string, value string) bool {
+	return len(patterns) == 0 || matchAny(patterns, value)
+}
+
+// anyGroup reports whether any of the groups is matched by the patterns
+func anyGroup(patterns, groups []string) bool {
+	for _, group := range groups {
+		if matchAny(patterns, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks the operator of the condition and the type of its value
+func (c *Condition) validate() error {
+	if c.Argument == "" {
+		return errors.New("condition without argument")
+	}
+	switch c.Op {
+	case "eq", "ne":
+	case "gt", "ge", "lt", "le":
+		if _, ok := toNumber(c.Value); !ok {
+			return fmt.Errorf("condition on %s: %s needs a number, got %v", c.Argument, c.Op, c.Value)
+		}
+	case "in", "notIn":
+		if _, ok := c.Value.([]any); !ok {
+			return fmt.Errorf("condition on %s: %s needs a list, got %v", c.Argument, c.Op, c.Value)
+		}
+	case "matches":
+		pattern, ok := c.Value.(string)
+		if !ok {
+			return fmt.Errorf("condition on %s: matches needs a glob pattern, got %v", c.Argument, c.Value)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("condition on %s: invalid pattern %q: %v", c.Argument, pattern, err)
+		}
+	default:
+		return fmt.Errorf("condition on %s: unknown op %q, must be eq, ne, gt, ge, lt, le, in, notIn or matches", c.Argument, c.Op)
+	}
+	return nil
+}
+
+// holds reports whether the argument satisfies the condition. A missing
+// argument only satisfies ne and notIn.
+func (c *Condition) holds(arguments map[string]any) bool {
+	value, ok := arguments[c.Argument]
+	if !ok {
+		return c.Op == "ne" || c.Op == "notIn"
+	}
+
+	switch c.Op {
+	case "eq":
+		return equal(value, c.Value)
+	case "ne":
+		return !equal(value, c.Value)
+	case "in", "notIn":
+		found := false
+		for _, candidate := range c.Value.([]any) {
+			if equal(value, candidate) {
+				found = true
+				break
+			}
+		}
+		return found == (c.Op == "in")
+	case "matches":
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		matched, _ := path.Match(c.Value.(string), s)
+		return matched
+	}
+
+	// Ordered comparisons, numbers may be passed as strings such as scale's replicas
+	left, ok := toNumber(value)
+	if !ok {
+		return false
+	}
+	right, _ := toNumber(c.Value)
+	switch c.Op {
+	case "gt":
+		return left > right
+	case "ge":
+		return left >= right
+	case "lt":
+		return left < right
+	case "le":
+		return left <= right
+	}
+	return false
+}
+
+// equal compares two argument values, numbers by value whatever their representation
+func equal(a, b any) bool {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return x == y
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// toNumber converts a JSON number or numeric string to a float
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,411 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEngineAuthorize(t *testing.T) {
+	scale := func(arguments map[string]any) *Request {
+		return &Request{Tool: "scale", Principal: "alice", Namespace: "shop", Context: "eu-prod", Arguments: arguments}
+	}
+	condition := func(op string, value any) Config {
+		return Config{Rules: []Rule{{
+			Effect: EffectDeny,
+			Tools:  []string{"scale"},
+			When:   []Condition{{Argument: "replicas", Op: op, Value: value}},
+		}}}
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		req    *Request
+		// deny is the rule expected to refuse the call, empty when the call is allowed
+		deny string
+	}{
+		{
+			name: "first matching rule wins over later rules",
+			config: Config{Rules: []Rule{
+				{Name: "allow-oncall", Effect: EffectAllow, Tools: []string{"delete_pod"}, Groups: []string{"oncall"}},
+				{Name: "deny-delete", Effect: EffectDeny, Tools: []string{"delete_pod"}},
+			}},
+			req: &Request{Tool: "delete_pod", Principal: "alice", Groups: []string{"dev", "oncall"}},
+		},
+		{
+			name: "later rule applies when earlier rules do not match",
+			config: Config{Rules: []Rule{
+				{Name: "allow-oncall", Effect: EffectAllow, Tools: []string{"delete_pod"}, Groups: []string{"oncall"}},
+				{Name: "deny-delete", Effect: EffectDeny, Tools: []string{"delete_pod"}},
+			}},
+			req:  &Request{Tool: "delete_pod", Principal: "bob", Groups: []string{"dev"}},
+			deny: "deny-delete",
+		},
+		{
+			name:   "default allow",
+			config: Config{Rules: []Rule{{Name: "deny-exec", Effect: EffectDeny, Tools: []string{"exec"}}}},
+			req:    &Request{Tool: "list_pods"},
+		},
+		{
+			name:   "default deny",
+			config: Config{Default: EffectDeny},
+			req:    &Request{Tool: "list_pods"},
+			deny:   "no policy rule allows list_pods",
+		},
+		{
+			name: "default deny with allowing rule",
+			config: Config{Default: EffectDeny, Rules: []Rule{
+				{Effect: EffectAllow, Tools: []string{"list_*", "describe_*"}},
+			}},
+			req: &Request{Tool: "describe_pod"},
+		},
+		{
+			name:   "unnamed rules are named by position",
+			config: Config{Rules: []Rule{{Effect: EffectAllow, Tools: []string{"exec"}}, {Effect: EffectDeny}}},
+			req:    &Request{Tool: "list_pods"},
+			deny:   "rule #2 denies list_pods",
+		},
+		{
+			name:   "message replaces the default reason",
+			config: Config{Rules: []Rule{{Name: "no-exec", Effect: EffectDeny, Tools: []string{"exec"}, Message: "use a debug pod"}}},
+			req:    &Request{Tool: "exec"},
+			deny:   "use a debug pod (rule no-exec)",
+		},
+		{
+			name:   "principal glob matches",
+			config: Config{Rules: []Rule{{Name: "bots", Effect: EffectDeny, Principals: []string{"bot-*"}}}},
+			req:    &Request{Tool: "list_pods", Principal: "bot-ci"},
+			deny:   "bots",
+		},
+		{
+			name:   "principal glob does not match",
+			config: Config{Rules: []Rule{{Name: "bots", Effect: EffectDeny, Principals: []string{"bot-*"}}}},
+			req:    &Request{Tool: "list_pods", Principal: "alice"},
+		},
+		{
+			name:   "group glob matches any group of the caller",
+			config: Config{Rules: []Rule{{Name: "contractors", Effect: EffectDeny, Groups: []string{"ext-*"}}}},
+			req:    &Request{Tool: "list_pods", Groups: []string{"dev", "ext-acme"}},
+			deny:   "contractors",
+		},
+		{
+			name:   "group selector does not match callers without groups",
+			config: Config{Rules: []Rule{{Name: "contractors", Effect: EffectDeny, Groups: []string{"*"}}}},
+			req:    &Request{Tool: "list_pods"},
+		},
+		{
+			name:   "namespace glob matches",
+			config: Config{Rules: []Rule{{Name: "prod", Effect: EffectDeny, Namespaces: []string{"prod-*"}}}},
+			req:    &Request{Tool: "delete_pod", Namespace: "prod-shop"},
+			deny:   "prod",
+		},
+		{
+			name:   "namespace glob does not match",
+			config: Config{Rules: []Rule{{Name: "prod", Effect: EffectDeny, Namespaces: []string{"prod-*"}}}},
+			req:    &Request{Tool: "delete_pod", Namespace: "staging"},
+		},
+		{
+			name:   "namespace selector does not match cluster-scoped calls",
+			config: Config{Rules: []Rule{{Name: "prod", Effect: EffectDeny, Namespaces: []string{"*"}}}},
+			req:    &Request{Tool: "cordon_node"},
+		},
+		{
+			name:   "listing all namespaces matches namespace-scoped deny rules",
+			config: Config{Rules: []Rule{{Name: "system", Effect: EffectDeny, Namespaces: []string{"kube-system"}}}},
+			req:    &Request{Tool: "list_pods", Namespace: AllNamespaces},
+			deny:   "system",
+		},
+		{
+			name: "listing all namespaces is not allowed by rules selecting some namespaces",
+			config: Config{Default: EffectDeny, Rules: []Rule{
+				{Effect: EffectAllow, Namespaces: []string{"team-*"}},
+			}},
+			req:  &Request{Tool: "list_pods", Namespace: AllNamespaces},
+			deny: "no policy rule allows list_pods",
+		},
+		{
+			name: "listing all namespaces is allowed by rules selecting every namespace",
+			config: Config{Default: EffectDeny, Rules: []Rule{
+				{Effect: EffectAllow, Namespaces: []string{"*"}},
+			}},
+			req: &Request{Tool: "list_pods", Namespace: AllNamespaces},
+		},
+		{
+			name:   "context glob matches",
+			config: Config{Rules: []Rule{{Name: "prod", Effect: EffectDeny, Contexts: []string{"*-prod"}}}},
+			req:    &Request{Tool: "exec", Context: "eu-prod"},
+			deny:   "prod",
+		},
+		{
+			name:   "context glob does not match",
+			config: Config{Rules: []Rule{{Name: "prod", Effect: EffectDeny, Contexts: []string{"*-prod"}}}},
+			req:    &Request{Tool: "exec", Context: "eu-staging"},
+		},
+		{
+			name: "all selectors must match",
+			config: Config{Rules: []Rule{{
+				Name: "prod", Effect: EffectDeny, Tools: []string{"exec"}, Namespaces: []string{"shop"}, Contexts: []string{"*-prod"},
+			}}},
+			req: &Request{Tool: "exec", Namespace: "billing", Context: "eu-prod"},
+		},
+		{
+			name:   "eq on a numeric string",
+			config: condition("eq", float64(0)),
+			req:    scale(map[string]any{"replicas": "0"}),
+			deny:   "#1",
+		},
+		{
+			name:   "eq on a different value",
+			config: condition("eq", float64(0)),
+			req:    scale(map[string]any{"replicas": "3"}),
+		},
+		{
+			name:   "eq on a boolean",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Argument: "force", Op: "eq", Value: true}}}}},
+			req:    &Request{Tool: "delete_pod", Arguments: map[string]any{"force": true}},
+			deny:   "#1",
+		},
+		{
+			name:   "ne on a different value",
+			config: condition("ne", float64(0)),
+			req:    scale(map[string]any{"replicas": float64(2)}),
+			deny:   "#1",
+		},
+		{
+			name:   "ne on an equal value",
+			config: condition("ne", float64(0)),
+			req:    scale(map[string]any{"replicas": "0"}),
+		},
+		{
+			name:   "gt above the value",
+			config: condition("gt", float64(50)),
+			req:    scale(map[string]any{"replicas": "51"}),
+			deny:   "#1",
+		},
+		{
+			name:   "gt at the value",
+			config: condition("gt", float64(50)),
+			req:    scale(map[string]any{"replicas": "50"}),
+		},
+		{
+			name:   "ge at the value",
+			config: condition("ge", float64(50)),
+			req:    scale(map[string]any{"replicas": float64(50)}),
+			deny:   "#1",
+		},
+		{
+			name:   "lt below the value",
+			config: condition("lt", float64(1)),
+			req:    scale(map[string]any{"replicas": "0"}),
+			deny:   "#1",
+		},
+		{
+			name:   "lt at the value",
+			config: condition("lt", float64(1)),
+			req:    scale(map[string]any{"replicas": "1"}),
+		},
+		{
+			name:   "le at the value",
+			config: condition("le", float64(1)),
+			req:    scale(map[string]any{"replicas": "1"}),
+			deny:   "#1",
+		},
+		{
+			name:   "ordered comparison on a non-numeric argument",
+			config: condition("gt", float64(50)),
+			req:    scale(map[string]any{"replicas": "many"}),
+		},
+		{
+			name:   "in with a matching value",
+			config: condition("in", []any{float64(0), float64(1)}),
+			req:    scale(map[string]any{"replicas": "1"}),
+			deny:   "#1",
+		},
+		{
+			name:   "in without a matching value",
+			config: condition("in", []any{float64(0), float64(1)}),
+			req:    scale(map[string]any{"replicas": "2"}),
+		},
+		{
+			name:   "notIn without a matching value",
+			config: condition("notIn", []any{float64(0), float64(1)}),
+			req:    scale(map[string]any{"replicas": "2"}),
+			deny:   "#1",
+		},
+		{
+			name:   "notIn with a matching value",
+			config: condition("notIn", []any{float64(0), float64(1)}),
+			req:    scale(map[string]any{"replicas": "0"}),
+		},
+		{
+			name:   "matches a glob",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Argument: "command", Op: "matches", Value: "rm *"}}}}},
+			req:    &Request{Tool: "exec", Arguments: map[string]any{"command": "rm -rf data"}},
+			deny:   "#1",
+		},
+		{
+			name:   "matches on a non-string argument",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Argument: "replicas", Op: "matches", Value: "*"}}}}},
+			req:    scale(map[string]any{"replicas": float64(3)}),
+		},
+		{
+			name:   "missing argument fails eq",
+			config: condition("eq", float64(0)),
+			req:    scale(nil),
+		},
+		{
+			name:   "missing argument fails gt",
+			config: condition("gt", float64(50)),
+			req:    scale(map[string]any{"name": "cart"}),
+		},
+		{
+			name:   "missing argument fails in",
+			config: condition("in", []any{float64(0)}),
+			req:    scale(nil),
+		},
+		{
+			name:   "missing argument fails matches",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Argument: "command", Op: "matches", Value: "*"}}}}},
+			req:    &Request{Tool: "exec"},
+		},
+		{
+			name:   "missing argument satisfies ne",
+			config: condition("ne", float64(0)),
+			req:    scale(nil),
+			deny:   "#1",
+		},
+		{
+			name:   "missing argument satisfies notIn",
+			config: condition("notIn", []any{float64(0)}),
+			req:    scale(nil),
+			deny:   "#1",
+		},
+		{
+			name: "all conditions must hold",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{
+				{Argument: "replicas", Op: "gt", Value: float64(10)},
+				{Argument: "name", Op: "eq", Value: "cart"},
+			}}}},
+			req: scale(map[string]any{"replicas": "20", "name": "checkout"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEngine(tt.config)
+			if err != nil {
+				t.Fatalf("NewEngine() error = %v", err)
+			}
+			if e == nil {
+				t.Fatal("NewEngine() returned no engine for a non-empty policy")
+			}
+
+			err = e.Authorize(tt.req)
+			switch {
+			case tt.deny == "" && err != nil:
+				t.Errorf("Authorize() error = %v, want the call allowed", err)
+			case tt.deny != "" && err == nil:
+				t.Errorf("Authorize() allowed the call, want it denied by %s", tt.deny)
+			case tt.deny != "" && !errors.Is(err, ErrPolicyViolation):
+				t.Errorf("Authorize() error = %v, want a policy violation", err)
+			case tt.deny != "" && !strings.Contains(err.Error(), tt.deny):
+				t.Errorf("Authorize() error = %v, want it to name %q", err, tt.deny)
+			}
+		})
+	}
+}
+
+func TestNewEngineEmptyPolicy(t *testing.T) {
+	for _, config := range []Config{{}, {Default: EffectAllow}} {
+		e, err := NewEngine(config)
+		if err != nil {
+			t.Fatalf("NewEngine(%+v) error = %v", config, err)
+		}
+		if e != nil {
+			t.Errorf("NewEngine(%+v) = %v, want no engine", config, e)
+		}
+	}
+}
+
+func TestNewEngineValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []string
+	}{
+		{
+			name:   "unknown default effect",
+			config: Config{Default: "block"},
+			want:   []string{`default: effect must be allow or deny, got "block"`},
+		},
+		{
+			name:   "missing rule effect",
+			config: Config{Rules: []Rule{{Tools: []string{"exec"}}}},
+			want:   []string{`rule #1: effect must be allow or deny, got ""`},
+		},
+		{
+			name:   "invalid tool pattern",
+			config: Config{Rules: []Rule{{Name: "bad", Effect: EffectDeny, Tools: []string{"list_["}}}},
+			want:   []string{`rule bad: invalid pattern "list_["`},
+		},
+		{
+			name:   "invalid namespace pattern",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, Namespaces: []string{"[a-"}}}},
+			want:   []string{`rule #1: invalid pattern "[a-"`},
+		},
+		{
+			name:   "condition without argument",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Op: "eq", Value: "x"}}}}},
+			want:   []string{"rule #1: condition without argument"},
+		},
+		{
+			name:   "unknown op",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Argument: "replicas", Op: "between"}}}}},
+			want:   []string{`condition on replicas: unknown op "between"`},
+		},
+		{
+			name:   "ordered comparison with a non-numeric value",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Argument: "replicas", Op: "gt", Value: "many"}}}}},
+			want:   []string{"condition on replicas: gt needs a number, got many"},
+		},
+		{
+			name:   "in with a scalar value",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Argument: "replicas", Op: "in", Value: float64(0)}}}}},
+			want:   []string{"condition on replicas: in needs a list, got 0"},
+		},
+		{
+			name:   "matches with a non-string value",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Argument: "command", Op: "matches", Value: float64(1)}}}}},
+			want:   []string{"condition on command: matches needs a glob pattern, got 1"},
+		},
+		{
+			name:   "matches with an invalid pattern",
+			config: Config{Rules: []Rule{{Effect: EffectDeny, When: []Condition{{Argument: "command", Op: "matches", Value: "rm ["}}}}},
+			want:   []string{`condition on command: invalid pattern "rm ["`},
+		},
+		{
+			name: "every invalid rule is reported",
+			config: Config{Default: "block", Rules: []Rule{
+				{Name: "first", Effect: "permit"},
+				{Effect: EffectAllow},
+				{Name: "third", Effect: EffectDeny, Contexts: []string{"["}},
+			}},
+			want: []string{"default: effect must be allow or deny", "rule first: effect must be allow or deny", `rule third: invalid pattern "["`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEngine(tt.config)
+			if err == nil {
+				t.Fatalf("NewEngine() = %v, want a validation error", e)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("NewEngine() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
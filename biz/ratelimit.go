@@ -0,0 +1,205 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz/session"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is wrapped by the errors of calls refused by the rate limits
+var ErrRateLimited = errors.New("rate limited")
+
+// RateLimit is a token bucket refilled with PerMinute calls per minute and holding up
+// to Burst calls. A zero PerMinute disables the limit, a zero Burst defaults to PerMinute.
+type RateLimit struct {
+	PerMinute float64 `json:"perMinute"`
+	Burst     int     `json:"burst"`
+}
+
+// Enabled reports whether the limit restricts calls
+func (l RateLimit) Enabled() bool {
+	return l.PerMinute > 0
+}
+
+// newLimiter returns a token bucket enforcing the limit
+func (l RateLimit) newLimiter() *rate.Limiter {
+	burst := l.Burst
+	if burst <= 0 {
+		burst = max(int(math.Ceil(l.PerMinute)), 1)
+	}
+	return rate.NewLimiter(rate.Limit(l.PerMinute/60), burst)
+}
+
+func (l RateLimit) String() string {
+	if l.Burst > 0 {
+		return strconv.FormatFloat(l.PerMinute, 'f', -1, 64) + ":" + strconv.Itoa(l.Burst)
+	}
+	return strconv.FormatFloat(l.PerMinute, 'f', -1, 64)
+}
+
+// ParseToolRateLimits parses per-tool rate limits given as "tool=perMinute[:burst],..."
+func ParseToolRateLimits(spec string) (map[string]RateLimit, error) {
+	perTool := make(map[string]RateLimit)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid tool rate limit %q, expected tool=perMinute[:burst]", entry)
+		}
+		perMinute, burst, hasBurst := strings.Cut(value, ":")
+		var limit RateLimit
+		var err error
+		if limit.PerMinute, err = strconv.ParseFloat(perMinute, 64); err != nil {
+			return nil, fmt.Errorf("invalid rate limit for tool %s: %v", name, err)
+		}
+		if hasBurst {
+			if limit.Burst, err = strconv.Atoi(burst); err != nil {
+				return nil, fmt.Errorf("invalid burst for tool %s: %v", name, err)
+			}
+		}
+		perTool[name] = limit
+	}
+	return perTool, nil
+}
+
+// sessionLimiters holds the token buckets of one session
+type sessionLimiters struct {
+	session *rate.Limiter
+	tools   map[string]*rate.Limiter
+}
+
+var rateLimits = struct {
+	sync.Mutex
+	session  RateLimit
+	perTool  map[string]RateLimit
+	sessions map[string]*sessionLimiters
+}{
+	perTool:  map[string]RateLimit{},
+	sessions: make(map[string]*sessionLimiters),
+}
+
+func init() {
+	// Release the buckets of ended sessions
+	session.OnClose(func(id string) {
+		rateLimits.Lock()
+		defer rateLimits.Unlock()
+		delete(rateLimits.sessions, id)
+	})
+}
+
+// SetRateLimits sets the rate limit of all tool calls of a session, and per-tool limits
+// applying to the calls of each tool within a session. Running buckets are reset.
+func SetRateLimits(perSession RateLimit, perTool map[string]RateLimit) {
+	rateLimits.Lock()
+	defer rateLimits.Unlock()
+	rateLimits.session = perSession
+	rateLimits.perTool = perTool
+	rateLimits.sessions = make(map[string]*sessionLimiters)
+}
+
+// reserve takes a token from the session's and the tool's bucket, or returns how long to
+// wait until both have one. Nothing is taken when the call has to wait.
+func reserve(sessionID, name string) time.Duration {
+	rateLimits.Lock()
+	defer rateLimits.Unlock()
+
+	toolLimit, limitTool := rateLimits.perTool[name]
+	limitTool = limitTool && toolLimit.Enabled()
+	if !rateLimits.session.Enabled() && !limitTool {
+		return 0
+	}
+
+	limiters, ok := rateLimits.sessions[sessionID]
+	if !ok {
+		limiters = &sessionLimiters{tools: make(map[string]*rate.Limiter)}
+		if rateLimits.session.Enabled() {
+			limiters.session = rateLimits.session.newLimiter()
+		}
+		rateLimits.sessions[sessionID] = limiters
+	}
+	buckets := make([]*rate.Limiter, 0, 2)
+	if limiters.session != nil {
+		buckets = append(buckets, limiters.session)
+	}
+	if limitTool {
+		if _, ok := limiters.tools[name]; !ok {
+			limiters.tools[name] = toolLimit.newLimiter()
+		}
+		buckets = append(buckets, limiters.tools[name])
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	reservations := make([]*rate.Reservation, 0, len(buckets))
+	for _, bucket := range buckets {
+		r := bucket.ReserveN(now, 1)
+		reservations = append(reservations, r)
+		wait = max(wait, r.DelayFrom(now))
+	}
+	if wait > 0 {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+	return wait
+}
+
+// withRateLimit refuses calls of the tool exceeding the session's or the tool's rate limit
+func withRateLimit(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		if wait := reserve(session.IDFromContext(ctx), name); wait > 0 {
+			return nil, fmt.Errorf("%w, retry after %d s", ErrRateLimited, int(math.Ceil(wait.Seconds())))
+		}
+		return next(ctx, req)
+	}
+}
+
+// streams counts the running exec and log streams against their global cap
+var streams = struct {
+	sync.Mutex
+	running int
+	max     int
+}{}
+
+// SetMaxStreams sets how many exec and log streams may run at once across all sessions.
+// A zero or negative limit disables the cap.
+func SetMaxStreams(limit int) {
+	streams.Lock()
+	defer streams.Unlock()
+	streams.max = limit
+}
+
+// withStreamLimit refuses calls of a streaming tool while the maximum number of streams runs
+func withStreamLimit(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		streams.Lock()
+		if streams.max > 0 && streams.running >= streams.max {
+			limit := streams.max
+			streams.Unlock()
+			return nil, fmt.Errorf("%w, %d exec and log streams are already running, retry after 1 s", ErrRateLimited, limit)
+		}
+		streams.running++
+		streams.Unlock()
+
+		defer func() {
+			streams.Lock()
+			streams.running--
+			streams.Unlock()
+		}()
+		return next(ctx, req)
+	}
+}
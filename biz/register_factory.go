@@ -1,12 +1,31 @@
 package biz
 
 import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz/audit"
+	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/logging"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/metrics"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/session"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 	"github.com/ThinkInAIXYZ/go-mcp/server"
 )
 
 // Tool register factory list
 var ToolRegisterFactory = make([]func(mcpServer *server.Server) error, 0)
 
+// registeredTools counts the tools registered with the MCP server
+var registeredTools atomic.Int64
+
+// ClusterParams defines the optional cluster context argument accepted by every cluster tool
+type ClusterParams struct {
+	Context string `json:"context"`
+}
+
 // ToolRegister register tool handler function
 func ToolRegister(fn func(mcpServer *server.Server) error) {
 	ToolRegisterFactory = append(ToolRegisterFactory, fn)
@@ -17,6 +36,11 @@ func RegisterHandler(handler ToolHandler) {
 	ToolRegisterFactory = append(ToolRegisterFactory, registerHandler(handler))
 }
 
+// RegisteredToolCount returns the number of tools registered with the MCP server
+func RegisteredToolCount() int {
+	return int(registeredTools.Load())
+}
+
 // registerHandler convert handler to register function
 func registerHandler(handler ToolHandler) func(mcpServer *server.Server) error {
 	return func(mcpServer *server.Server) error {
@@ -25,9 +49,64 @@ func registerHandler(handler ToolHandler) func(mcpServer *server.Server) error {
 			return err
 		}
 		for tool, handler := range tools {
-			mcpServer.RegisterTool(tool, handler)
+			if !toolEnabled(tool.Name) {
+				continue
+			}
+			handler = withOutputLimit(handler)
+			if isStreaming(tool.Name) {
+				handler = withStreamLimit(handler)
+			}
+			if requiresConfirmation(tool.Name) {
+				tool = withConfirmationArgument(tool)
+				handler = withConfirmation(tool.Name, handler)
+			}
+			// Rate limits and policy checks run inside the audit wrapper so that refused calls are audited too
+			handler = withRateLimit(tool.Name, withNamespaceGuard(withPolicy(tool.Name, withTimeout(tool.Name, handler))))
+			// The cluster context is resolved first so spans, logs and the audit record name the target cluster
+			handler = audit.Wrap(tool.Name, IsMutating(tool.Name), handler)
+			handler = withClusterContext(withTracing(tool.Name, withLogger(tool.Name, handler)))
+			mcpServer.RegisterTool(tool, trackInFlight(metrics.Wrap(tool.Name, handler)))
+			registeredTools.Add(1)
 		}
 		return nil
 	}
 }
 
+// withClusterContext makes the optional context argument of a tool call select
+// the cluster the handler talks to, without switching the session's context
+func withClusterContext(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		if params, err := ParseParams[ClusterParams](req); err == nil && params.Context != "" {
+			ctx = kubeclient.WithContextName(ctx, params.Context)
+		}
+		return next(ctx, req)
+	}
+}
+
+// withLogger attaches a logger carrying the tool, cluster context and namespace of the
+// call to its context, and logs the outcome of the call
+func withLogger(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		logger := logging.FromContext(ctx).With("tool", name)
+		if id := session.IDFromContext(ctx); id != "" {
+			logger = logger.With("session", id)
+		}
+		if contextName, err := kubeclient.ResolveContext(ctx); err == nil {
+			logger = logger.With("context", contextName)
+		}
+		if params, err := ParseParams[targetParams](req); err == nil && params.Namespace != "" {
+			logger = logger.With("namespace", params.Namespace)
+		}
+		ctx = logging.WithLogger(ctx, logger)
+
+		logger.Debug("Tool call started")
+		start := time.Now()
+		result, err := next(ctx, req)
+		if err != nil {
+			logger.Warn("Tool call failed", "duration", time.Since(start), "error", err)
+		} else {
+			logger.Debug("Tool call finished", "duration", time.Since(start))
+		}
+		return result, err
+	}
+}
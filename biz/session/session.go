@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+	"sync"
+)
+
+// idKey is the context key under which the MCP session ID is stored
+type idKey struct{}
+
+var (
+	hooksMu    sync.RWMutex
+	openHooks  []func(id string)
+	closeHooks []func(id string)
+)
+
+// WithID returns a copy of ctx carrying the given MCP session ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// IDFromContext returns the MCP session ID carried by ctx.
+// An empty string is returned for transports without sessions (e.g. stdio).
+func IDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// OnOpen registers a hook that is called when an MCP session starts
+func OnOpen(fn func(id string)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	openHooks = append(openHooks, fn)
+}
+
+// OnClose registers a hook that is called when an MCP session ends,
+// so packages can release per-session state
+func OnClose(fn func(id string)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	closeHooks = append(closeHooks, fn)
+}
+
+// Open notifies all registered hooks that the session has started
+func Open(id string) {
+	runHooks(&openHooks, id)
+}
+
+// Close notifies all registered hooks that the session has ended
+func Close(id string) {
+	runHooks(&closeHooks, id)
+}
+
+// runHooks calls a snapshot of the given hooks, so hooks may register further hooks
+func runHooks(registered *[]func(id string), id string) {
+	hooksMu.RLock()
+	hooks := make([]func(id string), len(*registered))
+	copy(hooks, *registered)
+	hooksMu.RUnlock()
+
+	for _, fn := range hooks {
+		fn(id)
+	}
+}
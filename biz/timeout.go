@@ -0,0 +1,84 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+)
+
+// DefaultToolTimeout bounds a tool call unless a per-tool timeout is configured
+const DefaultToolTimeout = 2 * time.Minute
+
+var toolTimeouts = struct {
+	sync.RWMutex
+	defaultTimeout time.Duration
+	perTool        map[string]time.Duration
+}{
+	defaultTimeout: DefaultToolTimeout,
+	perTool:        map[string]time.Duration{},
+}
+
+// SetToolTimeouts sets the default tool call timeout and per-tool overrides.
+// A zero or negative timeout disables the limit.
+func SetToolTimeouts(defaultTimeout time.Duration, perTool map[string]time.Duration) {
+	toolTimeouts.Lock()
+	defer toolTimeouts.Unlock()
+	toolTimeouts.defaultTimeout = defaultTimeout
+	toolTimeouts.perTool = perTool
+}
+
+// ParseToolTimeouts parses per-tool timeouts given as "tool=duration,tool=duration"
+func ParseToolTimeouts(spec string) (map[string]time.Duration, error) {
+	perTool := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid tool timeout %q, expected tool=duration", entry)
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for tool %s: %v", name, err)
+		}
+		perTool[name] = timeout
+	}
+	return perTool, nil
+}
+
+// toolTimeout returns the timeout of the named tool
+func toolTimeout(name string) time.Duration {
+	toolTimeouts.RLock()
+	defer toolTimeouts.RUnlock()
+	if timeout, ok := toolTimeouts.perTool[name]; ok {
+		return timeout
+	}
+	return toolTimeouts.defaultTimeout
+}
+
+// withTimeout bounds every call of the tool by its configured timeout
+func withTimeout(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		timeout := toolTimeout(name)
+		if timeout <= 0 {
+			return next(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result, err := next(ctx, req)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("tool %s timed out after %s: %v", name, timeout, err)
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,211 @@
+package biz
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+)
+
+// toolSpec describes how a tool is classified for filtering, and how its calls are previewed
+type toolSpec struct {
+	group            string
+	mutating         bool
+	dryRun           bool
+	streaming        bool
+	defaultNamespace string
+	preview          PreviewFunc
+}
+
+var toolSpecs = struct {
+	sync.RWMutex
+	specs    map[string]*toolSpec
+	readOnly bool
+	enabled  []string
+	disabled []string
+}{
+	specs: make(map[string]*toolSpec),
+}
+
+// spec gets or creates the spec of the named tool, the caller must hold the lock
+func spec(name string) *toolSpec {
+	s, ok := toolSpecs.specs[name]
+	if !ok {
+		s = &toolSpec{}
+		toolSpecs.specs[name] = s
+	}
+	return s
+}
+
+// DeclareGroup puts all tools of a handler into the named tool group, e.g. "pod"
+func DeclareGroup(group string, tools map[*protocol.Tool]server.ToolHandlerFunc) {
+	toolSpecs.Lock()
+	defer toolSpecs.Unlock()
+	for tool := range tools {
+		spec(tool.Name).group = group
+	}
+}
+
+// DeclareMutating declares tools that change cluster or server state
+func DeclareMutating(tools ...*protocol.Tool) {
+	toolSpecs.Lock()
+	defer toolSpecs.Unlock()
+	for _, tool := range tools {
+		spec(tool.Name).mutating = true
+	}
+}
+
+// DeclareDryRun declares write tools accepting the dryRun argument, whose calls with
+// dryRun set change nothing
+func DeclareDryRun(tools ...*protocol.Tool) {
+	toolSpecs.Lock()
+	defer toolSpecs.Unlock()
+	for _, tool := range tools {
+		spec(tool.Name).dryRun = true
+	}
+}
+
+// DeclareStreaming declares tools holding an exec or log stream open while they run,
+// counted against the global stream cap
+func DeclareStreaming(tools ...*protocol.Tool) {
+	toolSpecs.Lock()
+	defer toolSpecs.Unlock()
+	for _, tool := range tools {
+		spec(tool.Name).streaming = true
+	}
+}
+
+// DeclareDefaultNamespace declares the namespace tools target when called without one,
+// policy.AllNamespaces for tools listing all namespaces then
+func DeclareDefaultNamespace(namespace string, tools ...*protocol.Tool) {
+	toolSpecs.Lock()
+	defer toolSpecs.Unlock()
+	for _, tool := range tools {
+		spec(tool.Name).defaultNamespace = namespace
+	}
+}
+
+// DeclarePreview declares the function describing what a call of the tool would change,
+// shown when the tool requires confirmation
+func DeclarePreview(tool *protocol.Tool, preview PreviewFunc) {
+	toolSpecs.Lock()
+	defer toolSpecs.Unlock()
+	spec(tool.Name).preview = preview
+}
+
+// IsMutating reports whether the named tool was declared as mutating
+func IsMutating(name string) bool {
+	toolSpecs.RLock()
+	defer toolSpecs.RUnlock()
+	s, ok := toolSpecs.specs[name]
+	return ok && s.mutating
+}
+
+// supportsDryRun reports whether the named tool was declared as accepting the dryRun argument
+func supportsDryRun(name string) bool {
+	toolSpecs.RLock()
+	defer toolSpecs.RUnlock()
+	s, ok := toolSpecs.specs[name]
+	return ok && s.dryRun
+}
+
+// isStreaming reports whether the named tool was declared as holding a stream open
+func isStreaming(name string) bool {
+	toolSpecs.RLock()
+	defer toolSpecs.RUnlock()
+	s, ok := toolSpecs.specs[name]
+	return ok && s.streaming
+}
+
+// defaultNamespace returns the namespace the named tool targets when called without one
+func defaultNamespace(name string) string {
+	toolSpecs.RLock()
+	defer toolSpecs.RUnlock()
+	if s, ok := toolSpecs.specs[name]; ok {
+		return s.defaultNamespace
+	}
+	return ""
+}
+
+// SetReadOnly makes registration skip all mutating tools
+func SetReadOnly(enabled bool) {
+	toolSpecs.Lock()
+	defer toolSpecs.Unlock()
+	toolSpecs.readOnly = enabled
+}
+
+// SetToolFilter sets the tool patterns to enable and to disable. When enable is
+// empty every tool is enabled, disable always wins over enable.
+func SetToolFilter(enable, disable []string) error {
+	for _, pattern := range append(append([]string{}, enable...), disable...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid tool pattern %q: %v", pattern, err)
+		}
+	}
+
+	toolSpecs.Lock()
+	defer toolSpecs.Unlock()
+	toolSpecs.enabled = enable
+	toolSpecs.disabled = disable
+	return nil
+}
+
+// ParseToolPatterns splits a comma separated list of tool patterns
+func ParseToolPatterns(spec string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(spec, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// toolEnabled reports whether the named tool should be registered
+func toolEnabled(name string) bool {
+	toolSpecs.RLock()
+	defer toolSpecs.RUnlock()
+
+	s, ok := toolSpecs.specs[name]
+	if !ok {
+		s = &toolSpec{}
+	}
+	if toolSpecs.readOnly && s.mutating {
+		return false
+	}
+
+	ids := s.identifiers(name)
+	if len(toolSpecs.enabled) > 0 && !matchAny(toolSpecs.enabled, ids) {
+		return false
+	}
+	return !matchAny(toolSpecs.disabled, ids)
+}
+
+// identifiers returns the names a tool pattern can match: the tool name itself,
+// "group.tool" and "group.read" or "group.write"
+func (s *toolSpec) identifiers(name string) []string {
+	ids := []string{name}
+	if s.group == "" {
+		return ids
+	}
+	access := "read"
+	if s.mutating {
+		access = "write"
+	}
+	return append(ids, s.group+"."+name, s.group+"."+access)
+}
+
+// matchAny reports whether any of the glob patterns matches any of the identifiers
+func matchAny(patterns, ids []string) bool {
+	for _, pattern := range patterns {
+		for _, id := range ids {
+			if ok, _ := path.Match(pattern, id); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package biz
+
+import (
+	"context"
+
+	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/session"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/tracing"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// targetParams defines the arguments that name the object a tool call targets
+type targetParams struct {
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	PodName       string `json:"podName"`
+	NodeName      string `json:"nodeName"`
+	ConfigMapName string `json:"configMapName"`
+	ResourceName  string `json:"resourceName"`
+	ResourceType  string `json:"resourceType"`
+	AllNamespaces bool   `json:"allNamespaces"`
+}
+
+// resourceName returns the name of the targeted object, if the tool takes one
+func (p targetParams) resourceName() string {
+	for _, name := range []string{p.PodName, p.NodeName, p.ConfigMapName, p.ResourceName, p.Name} {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// withTracing runs the named tool in a span carrying the cluster context,
+// namespace and name of the object the call targets
+func withTracing(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		attrs := []attribute.KeyValue{attribute.String("mcp.tool.name", name)}
+		if id := session.IDFromContext(ctx); id != "" {
+			attrs = append(attrs, attribute.String("mcp.session.id", id))
+		}
+		if contextName, err := kubeclient.ResolveContext(ctx); err == nil {
+			attrs = append(attrs, attribute.String("k8s.cluster.context", contextName))
+		}
+		if params, err := ParseParams[targetParams](req); err == nil {
+			if params.Namespace != "" {
+				attrs = append(attrs, attribute.String("k8s.namespace.name", params.Namespace))
+			}
+			if params.ResourceType != "" {
+				attrs = append(attrs, attribute.String("k8s.resource.type", params.ResourceType))
+			}
+			if resourceName := params.resourceName(); resourceName != "" {
+				attrs = append(attrs, attribute.String("k8s.resource.name", resourceName))
+			}
+		}
+
+		ctx, span := tracing.Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+		defer span.End()
+
+		result, err := next(ctx, req)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else if result != nil && result.IsError {
+			span.SetStatus(codes.Error, "tool returned an error result")
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/auth"
+	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/logging"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/policy"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/tracing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// envPrefix prefixes the environment variables overriding settings,
+	// e.g. MCP_K8S_LOG_LEVEL overrides -log-level and logging.level
+	envPrefix = "MCP_K8S_"
+
+	// configEnv holds the configuration file path when -config is not given
+	configEnv = envPrefix + "CONFIG"
+)
+
+// Config holds all settings of the server. Settings come from the defaults, the
+// configuration file, MCP_K8S_* environment variables and command line flags, each
+// overriding the previous ones.
+type Config struct {
+	Server       ServerConfig       `json:"server"`
+	Kubernetes   KubernetesConfig   `json:"kubernetes"`
+	Tools        ToolsConfig        `json:"tools"`
+	Namespaces   NamespacesConfig   `json:"namespaces"`
+	Policy       policy.Config      `json:"policy"`
+	Confirmation ConfirmationConfig `json:"confirmation"`
+	RateLimits   RateLimitsConfig   `json:"rateLimits"`
+	Auth         AuthConfig         `json:"auth"`
+	Audit        AuditConfig        `json:"audit"`
+	Logging      LoggingConfig      `json:"logging"`
+	Tracing      TracingConfig      `json:"tracing"`
+}
+
+// ServerConfig holds the transport settings
+type ServerConfig struct {
+	Mode               string    `json:"mode"`
+	Address            string    `json:"address"`
+	ShutdownTimeout    Duration  `json:"shutdownTimeout"`
+	SessionIdleTimeout Duration  `json:"sessionIdleTimeout"`
+	Metrics            bool      `json:"metrics"`
+	TLS                TLSConfig `json:"tls"`
+}
+
+// TLSConfig holds the HTTPS and mutual TLS settings of the HTTP endpoints
+type TLSConfig struct {
+	Cert     string `json:"cert"`
+	Key      string `json:"key"`
+	ClientCA string `json:"clientCA"`
+}
+
+// KubernetesConfig holds the cluster access settings
+type KubernetesConfig struct {
+	Kubeconfig        string                         `json:"kubeconfig"`
+	Context           string                         `json:"context"`
+	InCluster         bool                           `json:"inCluster"`
+	ClientIdleTimeout Duration                       `json:"clientIdleTimeout"`
+	QPS               float64                        `json:"qps"`
+	Burst             int                            `json:"burst"`
+	Impersonation     kubeclient.ImpersonationConfig `json:"impersonation"`
+}
+
+// ToolsConfig holds the tool selection, timeout and output settings
+type ToolsConfig struct {
+	ReadOnly       bool        `json:"readOnly"`
+	Enable         stringList  `json:"enable"`
+	Disable        stringList  `json:"disable"`
+	Timeout        Duration    `json:"timeout"`
+	Timeouts       durationMap `json:"timeouts"`
+	MaxOutputBytes int         `json:"maxOutputBytes"`
+}
+
+// NamespacesConfig holds the glob patterns of the namespaces tools may and may not target
+type NamespacesConfig struct {
+	Allow stringList `json:"allow"`
+	Deny  stringList `json:"deny"`
+}
+
+// ConfirmationConfig holds the tools that run only after their preview has been confirmed
+type ConfirmationConfig struct {
+	Tools stringList `json:"tools"`
+	TTL   Duration   `json:"ttl"`
+}
+
+// RateLimitsConfig holds the token buckets limiting the tool calls of each session,
+// and the cap on concurrent exec and log streams
+type RateLimitsConfig struct {
+	Session    biz.RateLimit `json:"session"`
+	Tools      rateLimitMap  `json:"tools"`
+	MaxStreams int           `json:"maxStreams"`
+}
+
+// AuthConfig holds the API keys of the HTTP endpoints
+type AuthConfig struct {
+	APIKeysFile string   `json:"apiKeysFile"`
+	APIKeys     []string `json:"apiKeys"`
+}
+
+// AuditConfig holds the audit log settings
+type AuditConfig struct {
+	Log        string `json:"log"`
+	MaxSize    int    `json:"maxSize"`
+	MaxBackups int    `json:"maxBackups"`
+	Reads      bool   `json:"reads"`
+}
+
+// LoggingConfig holds the log settings
+type LoggingConfig struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+	File   string `json:"file"`
+}
+
+// TracingConfig holds the OpenTelemetry settings
+type TracingConfig struct {
+	Exporter     string `json:"exporter"`
+	OTLPEndpoint string `json:"otlpEndpoint"`
+	OTLPInsecure bool   `json:"otlpInsecure"`
+}
+
+// reloadableSettings are the settings applied on SIGHUP, all others need a restart
+var reloadableSettings = map[string]bool{
+	"server.shutdownTimeout":              true,
+	"kubernetes.clientIdleTimeout":        true,
+	"kubernetes.impersonation.principals": true,
+	"tools.timeout":                       true,
+	"tools.timeouts":                      true,
+	"tools.maxOutputBytes":                true,
+	"namespaces.allow":                    true,
+	"namespaces.deny":                     true,
+	"policy.default":                      true,
+	"policy.rules":                        true,
+	"confirmation.ttl":                    true,
+	"rateLimits.session.perMinute":        true,
+	"rateLimits.session.burst":            true,
+	"rateLimits.tools":                    true,
+	"rateLimits.maxStreams":               true,
+	"auth.apiKeysFile":                    true,
+	"auth.apiKeys":                        true,
+	"logging.level":                       true,
+}
+
+// defaultConfig returns the settings used when nothing else is configured
+func defaultConfig() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Mode:               "sse",
+			Address:            ":8686",
+			ShutdownTimeout:    Duration(30 * time.Second),
+			SessionIdleTimeout: Duration(DefaultSessionIdleTimeout),
+			Metrics:            true,
+		},
+		Kubernetes: KubernetesConfig{
+			ClientIdleTimeout: Duration(kubeclient.DefaultClientIdleTimeout),
+		},
+		Tools: ToolsConfig{
+			Timeout:  Duration(biz.DefaultToolTimeout),
+			Timeouts: durationMap{},
+		},
+		RateLimits: RateLimitsConfig{
+			Tools: rateLimitMap{},
+		},
+		Confirmation: ConfirmationConfig{
+			TTL: Duration(biz.DefaultConfirmationTTL),
+		},
+		Audit: AuditConfig{
+			MaxSize:    100,
+			MaxBackups: 10,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Tracing: TracingConfig{
+			Exporter: tracing.ExporterNone,
+		},
+	}
+}
+
+// bindFlags defines the command line flags of the settings on fs, using the current values as defaults
+func (c *Config) bindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Server.Mode, "mode", c.Server.Mode, "Transport mode: 'stdio', 'sse' or 'streamable'")
+	fs.StringVar(&c.Server.Address, "address", c.Server.Address, "Address for SSE and streamable HTTP server")
+	fs.DurationVar((*time.Duration)(&c.Server.ShutdownTimeout), "shutdown-timeout", time.Duration(c.Server.ShutdownTimeout), "How long to wait for running tool calls on SIGTERM before cancelling them")
+	fs.DurationVar((*time.Duration)(&c.Server.SessionIdleTimeout), "session-idle-timeout", time.Duration(c.Server.SessionIdleTimeout), "Close SSE and streamable HTTP sessions without requests for this long, 0 disables it")
+	fs.BoolVar(&c.Server.Metrics, "metrics", c.Server.Metrics, "Serve Prometheus metrics on /metrics in SSE and streamable HTTP mode")
+	fs.StringVar(&c.Server.TLS.Cert, "tls-cert", c.Server.TLS.Cert, "TLS certificate file, serves the HTTP endpoints over HTTPS together with -tls-key")
+	fs.StringVar(&c.Server.TLS.Key, "tls-key", c.Server.TLS.Key, "TLS private key file")
+	fs.StringVar(&c.Server.TLS.ClientCA, "client-ca", c.Server.TLS.ClientCA, "CA bundle used to verify the client certificates required on the MCP endpoints (mutual TLS)")
+
+	fs.StringVar(&c.Kubernetes.Kubeconfig, "kubeconfig", c.Kubernetes.Kubeconfig, "Kubeconfig file, directory or list of them merged like $KUBECONFIG, used by sessions that have not set their own; defaults to the standard loading rules")
+	fs.StringVar(&c.Kubernetes.Context, "context", c.Kubernetes.Context, "Context of sessions that have not switched context, defaults to the kubeconfig's current-context")
+	fs.BoolVar(&c.Kubernetes.InCluster, "in-cluster", c.Kubernetes.InCluster, "Authenticate with the mounted ServiceAccount token instead of a kubeconfig file (auto-detected in a Pod without kubeconfig)")
+	fs.DurationVar((*time.Duration)(&c.Kubernetes.ClientIdleTimeout), "client-idle-timeout", time.Duration(c.Kubernetes.ClientIdleTimeout), "Evict cached cluster clients unused for this long")
+	fs.Float64Var(&c.Kubernetes.QPS, "kube-qps", c.Kubernetes.QPS, "Client-side QPS limit of each cluster client, 0 keeps the client-go default")
+	fs.IntVar(&c.Kubernetes.Burst, "kube-burst", c.Kubernetes.Burst, "Client-side burst of each cluster client, 0 keeps the client-go default")
+	fs.BoolVar(&c.Kubernetes.Impersonation.Enabled, "impersonate", c.Kubernetes.Impersonation.Enabled, "Impersonate the Kubernetes user mapped to each authenticated caller in kubernetes.impersonation.principals")
+
+	fs.BoolVar(&c.Tools.ReadOnly, "read-only", c.Tools.ReadOnly, "Only register tools that do not change cluster or server state")
+	fs.Var(&c.Tools.Enable, "enable-tools", "Comma separated tool names, groups or globs to register, e.g. 'pod.*,kruise.read', default all")
+	fs.Var(&c.Tools.Disable, "disable-tools", "Comma separated tool names, groups or globs not to register, e.g. 'exec_command_in_pod,*.write'")
+	fs.DurationVar((*time.Duration)(&c.Tools.Timeout), "tool-timeout", time.Duration(c.Tools.Timeout), "Default timeout of a tool call, 0 disables it")
+	fs.Var(&c.Tools.Timeouts, "tool-timeouts", "Per-tool timeouts, e.g. 'exec_command_in_pod=5m,get_pod_logs=1m'")
+	fs.IntVar(&c.Tools.MaxOutputBytes, "max-output-bytes", c.Tools.MaxOutputBytes, "Truncate the text returned by a tool call to this many bytes, 0 disables it")
+
+	fs.Var(&c.Namespaces.Allow, "allow-namespaces", "Comma separated namespaces or globs tools may target, e.g. 'shop,team-*', default all")
+	fs.Var(&c.Namespaces.Deny, "deny-namespaces", "Comma separated namespaces or globs tools may not target, e.g. 'kube-system,kube-*'")
+
+	fs.Var(&c.Confirmation.Tools, "confirm-tools", "Comma separated tool names, groups or globs that only run once a preview has been confirmed, e.g. 'delete_pod,*.write'")
+	fs.DurationVar((*time.Duration)(&c.Confirmation.TTL), "confirmation-ttl", time.Duration(c.Confirmation.TTL), "How long the confirmation token of a preview stays valid")
+
+	fs.Float64Var(&c.RateLimits.Session.PerMinute, "session-rate-limit", c.RateLimits.Session.PerMinute, "Tool calls per minute allowed to each session, 0 disables the limit")
+	fs.IntVar(&c.RateLimits.Session.Burst, "session-rate-burst", c.RateLimits.Session.Burst, "Tool calls a session may make at once, defaults to -session-rate-limit")
+	fs.Var(&c.RateLimits.Tools, "tool-rate-limits", "Per-tool calls per minute and optional burst within a session, e.g. 'list_pods=30:5,get_pod_logs=10'")
+	fs.IntVar(&c.RateLimits.MaxStreams, "max-streams", c.RateLimits.MaxStreams, "Maximum number of concurrent exec and log streams across all sessions, 0 disables the cap")
+
+	fs.StringVar(&c.Auth.APIKeysFile, "api-keys-file", c.Auth.APIKeysFile, "File of 'principal:key[:group1,group2]' API keys required on the HTTP endpoints (also read from $"+auth.APIKeysEnv+")")
+
+	fs.StringVar(&c.Audit.Log, "audit-log", c.Audit.Log, "Write a JSON-lines audit record of tool calls to this file, or 'stdout'; disabled when empty")
+	fs.IntVar(&c.Audit.MaxSize, "audit-log-max-size", c.Audit.MaxSize, "Rotate the audit log file once it reaches this size in megabytes")
+	fs.IntVar(&c.Audit.MaxBackups, "audit-log-max-backups", c.Audit.MaxBackups, "Number of rotated audit log files to keep")
+	fs.BoolVar(&c.Audit.Reads, "audit-reads", c.Audit.Reads, "Also audit calls of read-only tools, not only mutating ones")
+
+	fs.StringVar(&c.Logging.Level, "log-level", c.Logging.Level, "Log level: 'debug', 'info', 'warn' or 'error'")
+	fs.StringVar(&c.Logging.Format, "log-format", c.Logging.Format, "Log format: 'text' or 'json'")
+	fs.StringVar(&c.Logging.File, "log-file", c.Logging.File, "Write logs to this file instead of stderr")
+
+	fs.StringVar(&c.Tracing.Exporter, "trace-exporter", c.Tracing.Exporter, "OpenTelemetry trace exporter: 'none', 'otlp' or 'stdout' (written to stderr)")
+	fs.StringVar(&c.Tracing.OTLPEndpoint, "otlp-endpoint", c.Tracing.OTLPEndpoint, "OTLP/HTTP endpoint as host:port or URL, defaults to $OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318")
+	fs.BoolVar(&c.Tracing.OTLPInsecure, "otlp-insecure", c.Tracing.OTLPInsecure, "Send traces to the OTLP endpoint over plain HTTP")
+}
+
+// loadConfig builds the configuration from the defaults, the configuration file at path
+// (if any), the environment and the explicitly set command line flags, and validates it
+func loadConfig(path string, explicitFlags map[string]string) (*Config, error) {
+	c := defaultConfig()
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	c.bindFlags(fs)
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config file: %v", err)
+		}
+		if err := yaml.UnmarshalStrict(data, c); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+	}
+
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		if value, ok := os.LookupEnv(name); ok {
+			if err := fs.Set(f.Name, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid value %q: %v", name, value, err))
+			}
+		}
+	})
+	for name, value := range explicitFlags {
+		if err := fs.Set(name, value); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// envName returns the environment variable overriding the named flag
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// validate checks the settings, reporting every invalid one with its configuration key
+func (c *Config) validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	switch c.Server.Mode {
+	case "stdio", "sse", "streamable":
+	default:
+		invalid("server.mode", "must be 'stdio', 'sse' or 'streamable', got %q", c.Server.Mode)
+	}
+	if c.Server.Mode != "stdio" && c.Server.Address == "" {
+		invalid("server.address", "must be set in %s mode", c.Server.Mode)
+	}
+	if c.Server.ShutdownTimeout < 0 {
+		invalid("server.shutdownTimeout", "must not be negative")
+	}
+	if c.Server.SessionIdleTimeout < 0 {
+		invalid("server.sessionIdleTimeout", "must not be negative")
+	}
+	if (c.Server.TLS.Cert == "") != (c.Server.TLS.Key == "") {
+		invalid("server.tls", "cert and key must be set together")
+	}
+	if c.Server.TLS.ClientCA != "" && c.Server.TLS.Cert == "" {
+		invalid("server.tls.clientCA", "requires cert and key")
+	}
+
+	if c.Kubernetes.InCluster && c.Kubernetes.Kubeconfig != "" {
+		invalid("kubernetes.inCluster", "cannot be combined with kubernetes.kubeconfig")
+	}
+	if c.Kubernetes.ClientIdleTimeout < 0 {
+		invalid("kubernetes.clientIdleTimeout", "must not be negative")
+	}
+	if c.Kubernetes.QPS < 0 {
+		invalid("kubernetes.qps", "must not be negative")
+	}
+	if c.Kubernetes.Burst < 0 {
+		invalid("kubernetes.burst", "must not be negative")
+	}
+	if err := c.Kubernetes.Impersonation.Validate(); err != nil {
+		invalid("kubernetes.impersonation", "%v", err)
+	}
+	if c.Kubernetes.Impersonation.Enabled && c.Server.Mode == "stdio" {
+		invalid("kubernetes.impersonation", "requires authenticated callers, not available in stdio mode")
+	}
+
+	for _, list := range []struct {
+		key      string
+		patterns []string
+	}{
+		{"tools.enable", c.Tools.Enable},
+		{"tools.disable", c.Tools.Disable},
+		{"namespaces.allow", c.Namespaces.Allow},
+		{"namespaces.deny", c.Namespaces.Deny},
+		{"confirmation.tools", c.Confirmation.Tools},
+	} {
+		for _, pattern := range list.patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				invalid(list.key, "invalid pattern %q: %v", pattern, err)
+			}
+		}
+	}
+	if c.Tools.MaxOutputBytes < 0 {
+		invalid("tools.maxOutputBytes", "must not be negative")
+	}
+
+	if _, err := policy.NewEngine(c.Policy); err != nil {
+		invalid("policy", "%v", err)
+	}
+	if c.Confirmation.TTL <= 0 {
+		invalid("confirmation.ttl", "must be positive")
+	}
+
+	if c.RateLimits.Session.PerMinute < 0 || c.RateLimits.Session.Burst < 0 {
+		invalid("rateLimits.session", "perMinute and burst must not be negative")
+	}
+	for name, limit := range c.RateLimits.Tools {
+		if limit.PerMinute < 0 || limit.Burst < 0 {
+			invalid("rateLimits.tools."+name, "perMinute and burst must not be negative")
+		}
+	}
+	if c.RateLimits.MaxStreams < 0 {
+		invalid("rateLimits.maxStreams", "must not be negative")
+	}
+
+	if c.Audit.Log == "stdout" && c.Server.Mode == "stdio" {
+		invalid("audit.log", "stdout cannot be used in stdio mode, write to a file instead")
+	}
+	if c.Audit.MaxSize <= 0 {
+		invalid("audit.maxSize", "must be positive")
+	}
+	if c.Audit.MaxBackups < 0 {
+		invalid("audit.maxBackups", "must not be negative")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		invalid("logging.level", "must be debug, info, warn or error, got %q", c.Logging.Level)
+	}
+	if format := strings.ToLower(c.Logging.Format); format != "text" && format != "json" {
+		invalid("logging.format", "must be text or json, got %q", c.Logging.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case "", tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		invalid("tracing.exporter", "must be 'none', 'otlp' or 'stdout', got %q", c.Tracing.Exporter)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// applyRuntimeSettings applies the settings that can change while the server runs
+func applyRuntimeSettings(c *Config) error {
+	if err := logging.SetLevel(c.Logging.Level); err != nil {
+		return err
+	}
+	kubeclient.SetClientIdleTimeout(time.Duration(c.Kubernetes.ClientIdleTimeout))
+	biz.SetToolTimeouts(time.Duration(c.Tools.Timeout), c.Tools.Timeouts.durations())
+	biz.SetMaxOutputBytes(c.Tools.MaxOutputBytes)
+	biz.SetConfirmationTTL(time.Duration(c.Confirmation.TTL))
+	biz.SetRateLimits(c.RateLimits.Session, c.RateLimits.Tools)
+	biz.SetMaxStreams(c.RateLimits.MaxStreams)
+	if err := kubeclient.SetImpersonation(c.Kubernetes.Impersonation); err != nil {
+		return err
+	}
+	if err := policy.SetNamespaceRestrictions(c.Namespaces.Allow, c.Namespaces.Deny); err != nil {
+		return err
+	}
+	return policy.SetPolicy(c.Policy)
+}
+
+// reloadConfig re-reads the configuration file and environment on SIGHUP and applies the
+// settings that are safe to change at runtime. An invalid configuration is rejected as a
+// whole, and changes of settings that need a restart are reported but not applied.
+func reloadConfig() {
+	next, err := loadConfig(configPath, explicitFlags)
+	if err != nil {
+		slog.Error("Failed to reload configuration, keeping the current one", "error", err)
+		return
+	}
+
+	if apiKeys != nil {
+		if err := apiKeys.Reload(next.Auth.APIKeysFile, next.Auth.APIKeys); err != nil {
+			slog.Error("Failed to reload API keys, keeping the current ones", "error", err)
+			next.Auth = cfg.Auth
+		}
+	} else if len(next.Auth.APIKeys) > 0 || next.Auth.APIKeysFile != "" {
+		slog.Warn("API keys were configured but authentication was disabled at startup, restart to enable it")
+	}
+
+	var changed, restart []string
+	diffSettings(reflect.ValueOf(*cfg), reflect.ValueOf(*next), "", &changed)
+	merged := *cfg
+	for _, key := range changed {
+		if !reloadableSettings[key] {
+			restart = append(restart, key)
+		}
+	}
+	merged.Server.ShutdownTimeout = next.Server.ShutdownTimeout
+	merged.Kubernetes.ClientIdleTimeout = next.Kubernetes.ClientIdleTimeout
+	merged.Kubernetes.Impersonation.Principals = next.Kubernetes.Impersonation.Principals
+	merged.Tools.Timeout = next.Tools.Timeout
+	merged.Tools.Timeouts = next.Tools.Timeouts
+	merged.Tools.MaxOutputBytes = next.Tools.MaxOutputBytes
+	merged.Namespaces = next.Namespaces
+	merged.Policy = next.Policy
+	merged.Confirmation.TTL = next.Confirmation.TTL
+	merged.RateLimits = next.RateLimits
+	merged.Auth = next.Auth
+	merged.Logging.Level = next.Logging.Level
+
+	if err := applyRuntimeSettings(&merged); err != nil {
+		slog.Error("Failed to apply reloaded configuration", "error", err)
+		return
+	}
+	cfg = &merged
+
+	if len(restart) > 0 {
+		slog.Warn("Changed settings need a restart to take effect", "settings", restart)
+	}
+	slog.Info("Configuration reloaded", "path", configPath)
+}
+
+// diffSettings appends the configuration keys of the leaf settings that differ between a and b
+func diffSettings(a, b reflect.Value, prefix string, changed *[]string) {
+	if a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*changed = append(*changed, prefix)
+		}
+		return
+	}
+	for i := 0; i < a.NumField(); i++ {
+		key, _, _ := strings.Cut(a.Type().Field(i).Tag.Get("json"), ",")
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		diffSettings(a.Field(i), b.Field(i), key, changed)
+	}
+}
+
+// Duration is a time.Duration written as a string such as "30s" in the configuration file
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration %s, expected a string such as \"30s\"", data)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// stringList is a list setting, given as a comma separated value on the command line
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = biz.ParseToolPatterns(value)
+	return nil
+}
+
+// durationMap maps tool names to durations, given as "tool=duration,tool=duration" on the command line
+type durationMap map[string]Duration
+
+func (m *durationMap) String() string {
+	entries := make([]string, 0, len(*m))
+	for name, d := range *m {
+		entries = append(entries, name+"="+time.Duration(d).String())
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func (m *durationMap) Set(value string) error {
+	perTool, err := biz.ParseToolTimeouts(value)
+	if err != nil {
+		return err
+	}
+	*m = make(durationMap, len(perTool))
+	for name, d := range perTool {
+		(*m)[name] = Duration(d)
+	}
+	return nil
+}
+
+// durations returns the map with plain time.Duration values
+func (m durationMap) durations() map[string]time.Duration {
+	perTool := make(map[string]time.Duration, len(m))
+	for name, d := range m {
+		perTool[name] = time.Duration(d)
+	}
+	return perTool
+}
+
+// rateLimitMap maps tool names to rate limits, given as "tool=perMinute[:burst],..." on the command line
+type rateLimitMap map[string]biz.RateLimit
+
+func (m *rateLimitMap) String() string {
+	entries := make([]string, 0, len(*m))
+	for name, limit := range *m {
+		entries = append(entries, name+"="+limit.String())
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func (m *rateLimitMap) Set(value string) error {
+	perTool, err := biz.ParseToolRateLimits(value)
+	if err != nil {
+		return err
+	}
+	*m = perTool
+	return nil
+}
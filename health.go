@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz"
+	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+)
+
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+	versionPath = "/version"
+
+	// goMCPModule is the module path of the MCP library reported on the version endpoint
+	goMCPModule = "github.com/ThinkInAIXYZ/go-mcp"
+
+	// readinessCacheTTL is how long a readiness check result is reused
+	readinessCacheTTL = 10 * time.Second
+	// readinessTimeout bounds the API server request of a readiness check
+	readinessTimeout = 3 * time.Second
+)
+
+// readiness caches the result of the last API server reachability check, so that
+// frequent probes do not each send a request to the API server
+var readiness = struct {
+	sync.Mutex
+	checked time.Time
+	result  readinessResult
+}{}
+
+// readinessResult is the body of the readiness endpoint
+type readinessResult struct {
+	Ready         bool   `json:"ready"`
+	Context       string `json:"context,omitempty"`
+	ServerVersion string `json:"serverVersion,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// versionInfo is the body of the version endpoint
+type versionInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	GoVersion string `json:"goVersion"`
+	GoMCP     string `json:"goMcpVersion,omitempty"`
+	Tools     int    `json:"registeredTools"`
+}
+
+// registerHealthHandlers adds the liveness, readiness and version endpoints to mux
+func registerHealthHandlers(mux *http.ServeMux) {
+	mux.HandleFunc(healthzPath, handleHealthz)
+	mux.HandleFunc(readyzPath, handleReadyz)
+	mux.HandleFunc(versionPath, handleVersion)
+}
+
+// handleHealthz reports that the process is alive
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// handleReadyz reports whether the API server of the current or default context is reachable
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	result := checkReadiness()
+	status := http.StatusOK
+	if !result.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, result)
+}
+
+// handleVersion reports build information and the number of registered tools
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	info := versionInfo{
+		Version:   "(devel)",
+		GoVersion: runtime.Version(),
+		Tools:     biz.RegisteredToolCount(),
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if build.Main.Version != "" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Revision = setting.Value
+			}
+		}
+		for _, dep := range build.Deps {
+			if dep.Path == goMCPModule {
+				info.GoMCP = dep.Version
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// checkReadiness returns the cached readiness result, refreshing it once it is older than readinessCacheTTL
+func checkReadiness() readinessResult {
+	readiness.Lock()
+	defer readiness.Unlock()
+
+	if draining.Load() {
+		return readinessResult{Error: "server is shutting down"}
+	}
+	if time.Since(readiness.checked) < readinessCacheTTL {
+		return readiness.result
+	}
+
+	// Probe the configured default cluster, which no session can switch
+	result := readinessResult{}
+	contextName, info, err := kubeclient.DefaultServerVersion(readinessTimeout)
+	result.Context = contextName
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Ready = true
+		result.ServerVersion = info.GitVersion
+	}
+
+	readiness.checked = time.Now()
+	readiness.result = result
+	return result
+}
+
+// writeJSON writes v as the JSON body of the response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beastpu/mcp-k8s-sse-server/biz/auth"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/metrics"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/session"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
+)
+
+const (
+	ssePath        = "/sse"
+	messagePath    = "/message"
+	streamablePath = "/mcp"
+	metricsPath    = "/metrics"
+
+	// sessionIDHeader carries the session ID in the streamable HTTP transport
+	sessionIDHeader = "Mcp-Session-Id"
+
+	// DefaultSessionIdleTimeout is how long a session may go without requests before it is closed
+	DefaultSessionIdleTimeout = 30 * time.Minute
+
+	// sessionSweepInterval is how often idle streamable HTTP sessions are looked for. It
+	// matches go-mcp's own idle check, so a session idle for the timeout plus one interval
+	// has been closed by go-mcp.
+	sessionSweepInterval = time.Minute
+)
+
+var (
+	// sessionOwners maps session IDs to the principal that opened the session
+	sessionOwners sync.Map
+
+	// draining is set on shutdown, when no new session may be opened
+	draining atomic.Bool
+
+	// streamableSessions records when each open streamable HTTP session was last used.
+	// go-mcp closes idle sessions without notice, so they are swept here as well.
+	streamableSessions = struct {
+		sync.Mutex
+		lastActive map[string]time.Time
+	}{lastActive: make(map[string]time.Time)}
+)
+
+// newSSEServer creates the SSE transport together with the HTTP server exposing it.
+// The endpoints are wrapped so that tool handlers can see which MCP session and
+// principal is calling. Authentication is disabled when no authenticator is given.
+func newSSEServer(addr string, authenticators []auth.Authenticator) (transport.ServerTransport, *http.Server, error) {
+	sseTransport, handler, err := transport.NewSSEServerTransportAndHandler(messagePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mux := newServeMux()
+	mux.Handle(ssePath, authenticate(authenticators, trackSession(handler.HandleSSE())))
+	mux.Handle(messagePath, authenticate(authenticators, withSessionID(handler.HandleMessage())))
+
+	return sseTransport, newHTTPServer(addr, mux), nil
+}
+
+// newStreamableServer creates the stateful streamable HTTP transport together with the
+// HTTP server exposing its single endpoint, wrapped like the SSE endpoints. Sessions
+// without requests for idleTimeout are closed, 0 keeps them until they are deleted.
+func newStreamableServer(addr string, authenticators []auth.Authenticator, idleTimeout time.Duration) (transport.ServerTransport, *http.Server, error) {
+	streamableTransport, handler, err := transport.NewStreamableHTTPServerTransportAndHandler(
+		transport.WithStreamableHTTPServerTransportAndHandlerOptionStateMode(transport.Stateful))
+	if err != nil {
+		return nil, nil, err
+	}
+	if idleTimeout > 0 {
+		go sweepIdleSessions(idleTimeout)
+	}
+
+	mux := newServeMux()
+	mux.Handle(streamablePath, authenticate(authenticators, trackStreamableSession(handler.HandleMCP())))
+
+	return streamableTransport, newHTTPServer(addr, mux), nil
+}
+
+// newServeMux creates the mux of the network transports with the endpoints they share.
+// These are not authenticated, so that monitoring systems and probes can reach them.
+func newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	registerHealthHandlers(mux)
+	if cfg.Server.Metrics {
+		mux.Handle(metricsPath, metrics.Handler())
+	}
+	return mux
+}
+
+// newHTTPServer creates the HTTP server of the network transports
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:        addr,
+		Handler:     handler,
+		IdleTimeout: time.Minute,
+	}
+}
+
+// authenticate puts the configured authenticators in front of next
+func authenticate(authenticators []auth.Authenticator, next http.Handler) http.Handler {
+	if len(authenticators) == 0 {
+		return next
+	}
+	return auth.Middleware(next, authenticators...)
+}
+
+// withSessionID stores the session ID of a message request in its context and
+// rejects messages sent by another principal than the one owning the session
+func withSessionID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionID := r.URL.Query().Get("sessionID")
+		if !ownsSession(r, sessionID) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		ctx := session.WithID(r.Context(), sessionID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ownsSession reports whether the caller of r may use the given session
+func ownsSession(r *http.Request, sessionID string) bool {
+	owner, ok := sessionOwners.Load(sessionID)
+	return !ok || owner == auth.PrincipalName(r.Context())
+}
+
+// trackStreamableSession stores the session ID of a streamable HTTP request in its
+// context, records the owner of sessions created by initialize requests and
+// notifies the session package when a session is deleted or found closed by go-mcp
+func trackStreamableSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionID := r.Header.Get(sessionIDHeader)
+		if sessionID == "" {
+			if draining.Load() {
+				http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+				return
+			}
+
+			// Only an initialize request may come without a session, other messages would
+			// share the state of the empty session ID
+			if r.Method == http.MethodPost && !isInitializeRequest(r) {
+				http.Error(w, "Missing "+sessionIDHeader+" header, only initialize may be sent without a session", http.StatusBadRequest)
+				return
+			}
+
+			// Initialize request, the transport assigns the session ID in the response header
+			next.ServeHTTP(&sessionHeaderWriter{ResponseWriter: w, r: r}, r)
+			return
+		}
+
+		if !ownsSession(r, sessionID) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		touchStreamableSession(sessionID)
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r.WithContext(session.WithID(r.Context(), sessionID)))
+		touchStreamableSession(sessionID)
+
+		// The transport answers 404 for sessions it closed, e.g. after they went idle
+		if r.Method == http.MethodDelete || sw.status == http.StatusNotFound {
+			closeStreamableSession(sessionID)
+		}
+	})
+}
+
+// isInitializeRequest reports whether the body of r is a JSON-RPC initialize request,
+// leaving the body in place for the transport
+func isInitializeRequest(r *http.Request) bool {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	var message struct {
+		Method protocol.Method `json:"method"`
+	}
+	return json.Unmarshal(body, &message) == nil && message.Method == protocol.Initialize
+}
+
+// touchStreamableSession records that an open streamable HTTP session is in use
+func touchStreamableSession(sessionID string) {
+	streamableSessions.Lock()
+	defer streamableSessions.Unlock()
+	if _, ok := streamableSessions.lastActive[sessionID]; ok {
+		streamableSessions.lastActive[sessionID] = time.Now()
+	}
+}
+
+// closeStreamableSession releases the state of a streamable HTTP session, once
+func closeStreamableSession(sessionID string) {
+	streamableSessions.Lock()
+	_, ok := streamableSessions.lastActive[sessionID]
+	delete(streamableSessions.lastActive, sessionID)
+	streamableSessions.Unlock()
+
+	if ok {
+		sessionOwners.Delete(sessionID)
+		session.Close(sessionID)
+	}
+}
+
+// sweepIdleSessions periodically closes the streamable HTTP sessions that go-mcp dropped
+// for being idle longer than idleTimeout, and that the client never came back to
+func sweepIdleSessions(idleTimeout time.Duration) {
+	ticker := time.NewTicker(sessionSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var idle []string
+		streamableSessions.Lock()
+		for sessionID, lastActive := range streamableSessions.lastActive {
+			if time.Since(lastActive) > idleTimeout+sessionSweepInterval {
+				idle = append(idle, sessionID)
+			}
+		}
+		streamableSessions.Unlock()
+
+		for _, sessionID := range idle {
+			slog.Debug("Closing idle streamable HTTP session", "session", sessionID)
+			closeStreamableSession(sessionID)
+		}
+	}
+}
+
+// statusWriter records the status code of a response, keeping streamed responses flushable
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// sessionHeaderWriter records the owner of the session ID returned by an initialize request
+type sessionHeaderWriter struct {
+	http.ResponseWriter
+	r *http.Request
+}
+
+func (w *sessionHeaderWriter) WriteHeader(code int) {
+	if sessionID := w.Header().Get(sessionIDHeader); sessionID != "" && code < http.StatusBadRequest {
+		if _, ok := auth.PrincipalFromContext(w.r.Context()); ok {
+			sessionOwners.Store(sessionID, auth.PrincipalName(w.r.Context()))
+		}
+		streamableSessions.Lock()
+		streamableSessions.lastActive[sessionID] = time.Now()
+		streamableSessions.Unlock()
+		session.Open(sessionID)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// trackSession notifies the session package once an SSE stream, and thus its session, ends
+func trackSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if draining.Load() {
+			http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+
+		tw := &sessionTrackingWriter{ResponseWriter: w}
+		if _, ok := auth.PrincipalFromContext(r.Context()); ok {
+			tw.owner = auth.PrincipalName(r.Context())
+		}
+		next.ServeHTTP(tw, r)
+		if tw.sessionID != "" {
+			sessionOwners.Delete(tw.sessionID)
+			session.Close(tw.sessionID)
+		}
+	})
+}
+
+// sessionTrackingWriter picks the session ID out of the endpoint event
+// that go-mcp writes first on every SSE stream
+type sessionTrackingWriter struct {
+	http.ResponseWriter
+	sessionID string
+	owner     string
+}
+
+func (w *sessionTrackingWriter) Write(b []byte) (int, error) {
+	if w.sessionID == "" {
+		if i := bytes.Index(b, []byte("sessionID=")); i >= 0 {
+			id := b[i+len("sessionID="):]
+			if end := bytes.IndexAny(id, "&\r\n"); end >= 0 {
+				id = id[:end]
+			}
+			w.sessionID = string(id)
+			if w.owner != "" {
+				sessionOwners.Store(w.sessionID, w.owner)
+			}
+			session.Open(w.sessionID)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *sessionTrackingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -1,10 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"io"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/beastpu/mcp-k8s-sse-server/biz"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/audit"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/auth"
+	kubeclient "github.com/beastpu/mcp-k8s-sse-server/biz/clientset"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/logging"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/policy"
+	"github.com/beastpu/mcp-k8s-sse-server/biz/tracing"
 	// Import sub-packages to execute init functions
 	_ "github.com/beastpu/mcp-k8s-sse-server/biz/configmap"
 	_ "github.com/beastpu/mcp-k8s-sse-server/biz/context"
@@ -17,43 +33,151 @@ import (
 )
 
 var (
-	mode    string
-	address string
+	// cfg holds the active settings, replaced with the runtime settings of the reloaded
+	// configuration on SIGHUP
+	cfg = defaultConfig()
+
+	// configPath is the configuration file given by -config or $MCP_K8S_CONFIG
+	configPath string
+
+	// explicitFlags holds the flags set on the command line, which win over the configuration file
+	explicitFlags = map[string]string{}
+
+	// apiKeys is the API key authenticator reloaded on SIGHUP, nil when no key is configured
+	apiKeys *auth.APIKeyAuthenticator
 )
 
+// shutdownGracePeriod is how long cancelled tool calls and the transports get to wind down
+const shutdownGracePeriod = 5 * time.Second
+
 func main() {
-	flag.StringVar(&mode, "mode", "sse", "Transport mode: 'stdio' or 'sse'")
-	flag.StringVar(&address, "address", ":8686", "Address for SSE server")
+	flag.StringVar(&configPath, "config", os.Getenv(configEnv), "YAML configuration file, reloaded on SIGHUP (also read from $"+configEnv+")")
+	// The command line only records which flags are set, loadConfig layers them over the file
+	defaultConfig().bindFlags(flag.CommandLine)
 	flag.Parse()
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "config" {
+			explicitFlags[f.Name] = f.Value.String()
+		}
+	})
+
+	var err error
+	if cfg, err = loadConfig(configPath, explicitFlags); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	if err := logging.Setup(cfg.Logging.Level, cfg.Logging.Format, cfg.Logging.File); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	if configPath != "" {
+		slog.Info("Loaded configuration file", "path", configPath)
+	}
+
+	if err := applyRuntimeSettings(cfg); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	kubeconfigPath := cfg.Kubernetes.Kubeconfig
+	if kubeconfigPath != "" {
+		// Directories and lists of files are merged like $KUBECONFIG
+		files, err := kubeclient.ExpandKubeconfigPaths(filepath.SplitList(kubeconfigPath))
+		if err != nil {
+			log.Fatalf("Invalid kubeconfig: %v", err)
+		}
+		kubeconfigPath = kubeclient.JoinKubeconfigPaths(files)
+	}
+	kubeclient.SetDefaultKubeconfig(kubeconfigPath, cfg.Kubernetes.Context)
+	kubeclient.SetClientRateLimits(float32(cfg.Kubernetes.QPS), cfg.Kubernetes.Burst)
+
+	if cfg.Tools.ReadOnly {
+		biz.SetReadOnly(true)
+		slog.Info("Read-only mode, mutating tools are not registered")
+	}
+	if err := biz.SetToolFilter(cfg.Tools.Enable, cfg.Tools.Disable); err != nil {
+		log.Fatalf("Invalid tool filter: %v", err)
+	}
+	if err := biz.SetConfirmTools(cfg.Confirmation.Tools); err != nil {
+		log.Fatalf("Invalid confirmation tools: %v", err)
+	}
+	if policy.Restricted() {
+		slog.Info("Namespace restrictions enabled", "allow", cfg.Namespaces.Allow, "deny", cfg.Namespaces.Deny)
+	}
+
+	auditCloser, err := setupAuditLog()
+	if err != nil {
+		log.Fatalf("Invalid audit log configuration: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.OTLPEndpoint, cfg.Tracing.OTLPInsecure)
+	if err != nil {
+		log.Fatalf("Invalid tracing configuration: %v", err)
+	}
+
+	if cfg.Kubernetes.InCluster || (cfg.Kubernetes.Kubeconfig == "" && kubeclient.DetectInCluster()) {
+		kubeclient.SetInClusterMode(true)
+		slog.Info("Using in-cluster ServiceAccount credentials")
+	}
 
 	// Start the server
-	if err := Start(); err != nil {
+	err = Start()
+	if auditCloser != nil {
+		auditCloser.Close()
+	}
+	flushTracing(shutdownTracing)
+	if err != nil {
 		log.Fatalf("Server startup failed: %v", err)
 	}
 }
 
 func Start() error {
 	var transportServer transport.ServerTransport
+	var httpServer *http.Server
 	var err error
 
-	switch mode {
+	switch cfg.Server.Mode {
 	case "stdio":
 		// Use standard input/output for transport
 		transportServer = transport.NewStdioServerTransport()
-		log.Println("Starting in stdio mode")
+		slog.Info("Starting in stdio mode")
 	case "sse":
-		// Use SSE for transport
-		transportServer, err = transport.NewSSEServerTransport(address)
+		authenticators, err := loadAuthenticators()
+		if err != nil {
+			return err
+		}
+
+		// Use SSE for transport, served by our own HTTP server so requests carry their session ID
+		transportServer, httpServer, err = newSSEServer(cfg.Server.Address, authenticators)
+		if err != nil {
+			return err
+		}
+		if err = configureTLS(httpServer); err != nil {
+			return err
+		}
+		slog.Info("Starting in SSE mode", "address", cfg.Server.Address)
+	case "streamable":
+		authenticators, err := loadAuthenticators()
+		if err != nil {
+			return err
+		}
+
+		// Use streamable HTTP for transport, with a single endpoint and resumable streams
+		transportServer, httpServer, err = newStreamableServer(cfg.Server.Address, authenticators, time.Duration(cfg.Server.SessionIdleTimeout))
 		if err != nil {
 			return err
 		}
-		log.Printf("Starting in SSE mode on %s\n", address)
+		if err = configureTLS(httpServer); err != nil {
+			return err
+		}
+		slog.Info("Starting in streamable HTTP mode", "address", cfg.Server.Address, "path", streamablePath)
 	default:
-		log.Fatalf("Invalid mode: %s. Must be 'stdio' or 'sse'\n", mode)
+		log.Fatalf("Invalid mode: %s. Must be 'stdio', 'sse' or 'streamable'\n", cfg.Server.Mode)
 	}
 
-	// Initialize MCP server
-	mcpServer, err := server.NewServer(transportServer)
+	// Initialize MCP server, closing network sessions left idle
+	var serverOptions []server.Option
+	if httpServer != nil && cfg.Server.SessionIdleTimeout > 0 {
+		serverOptions = append(serverOptions, server.WithSessionMaxIdleTime(time.Duration(cfg.Server.SessionIdleTimeout)))
+	}
+	mcpServer, err := server.NewServer(transportServer, serverOptions...)
 	if err != nil {
 		return err
 	}
@@ -65,9 +189,143 @@ func Start() error {
 		}
 	}
 
+	// Start HTTP server for network transports
+	if httpServer != nil {
+		go func() {
+			if err := serveHTTP(httpServer); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("HTTP server failed: %v", err)
+			}
+		}()
+	}
+
 	// Start server
-	if err = mcpServer.Run(); err != nil {
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- mcpServer.Run()
+	}()
+
+	// Run until the server fails or a termination signal arrives, reloading the configuration on SIGHUP
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	for running := true; running; {
+		select {
+		case err = <-runErr:
+			return err
+		case <-reload:
+			reloadConfig()
+		case <-signalCtx.Done():
+			running = false
+		}
+	}
+	stop()
+
+	return shutdown(mcpServer, httpServer)
+}
+
+// shutdown stops accepting new sessions, drains running tool calls and then
+// shuts down the MCP server and its HTTP server
+func shutdown(mcpServer *server.Server, httpServer *http.Server) error {
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeout)
+	slog.Info("Shutting down, waiting for running tool calls", "timeout", shutdownTimeout)
+	draining.Store(true)
+
+	if cancelled := biz.DrainToolCalls(shutdownTimeout, shutdownGracePeriod); cancelled > 0 {
+		slog.Warn("Cancelled tool calls still running after shutdown timeout", "cancelled", cancelled, "timeout", shutdownTimeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+	defer cancel()
+
+	if err := mcpServer.Shutdown(ctx); err != nil {
 		return err
 	}
+	if httpServer != nil {
+		if err := httpServer.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Server stopped")
 	return nil
 }
+
+// flushTracing exports the spans still buffered before the process exits
+func flushTracing(shutdownTracing func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+}
+
+// setupAuditLog enables the configured audit log and returns the file to close on exit.
+// Writing to stdout in stdio mode, where stdout carries the MCP protocol, is rejected by validate.
+func setupAuditLog() (io.Closer, error) {
+	switch cfg.Audit.Log {
+	case "":
+		return nil, nil
+	case "stdout":
+		audit.SetLogger(audit.NewLogger(os.Stdout, cfg.Audit.Reads))
+		return nil, nil
+	default:
+		file := audit.NewRotatingFile(cfg.Audit.Log, cfg.Audit.MaxSize, cfg.Audit.MaxBackups)
+		audit.SetLogger(audit.NewLogger(file, cfg.Audit.Reads))
+		slog.Info("Writing audit log", "path", cfg.Audit.Log)
+		return file, nil
+	}
+}
+
+// loadAuthenticators returns the configured authenticators, none meaning
+// the SSE endpoints are unauthenticated
+func loadAuthenticators() ([]auth.Authenticator, error) {
+	var authenticators []auth.Authenticator
+
+	// Verified client certificates identify the caller in mutual TLS mode
+	if cfg.Server.TLS.ClientCA != "" {
+		authenticators = append(authenticators, auth.ClientCertAuthenticator{})
+	}
+
+	var err error
+	apiKeys, err = auth.NewAPIKeyAuthenticator(cfg.Auth.APIKeysFile, cfg.Auth.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	if apiKeys != nil {
+		authenticators = append(authenticators, apiKeys)
+	}
+
+	if len(authenticators) == 0 {
+		if cfg.Kubernetes.Impersonation.Enabled {
+			return nil, errors.New("impersonation requires authenticated callers, configure API keys or a client CA")
+		}
+		slog.Warn("No API keys or client CA configured, HTTP endpoints are unauthenticated")
+	}
+	return authenticators, nil
+}
+
+// configureTLS enables HTTPS, and optionally client certificate verification, on the HTTP server.
+// Incomplete TLS settings are rejected by validate.
+func configureTLS(httpServer *http.Server) error {
+	if cfg.Server.TLS.Cert == "" {
+		return nil
+	}
+
+	tlsConfig, err := auth.NewServerTLSConfig(cfg.Server.TLS.ClientCA)
+	if err != nil {
+		return err
+	}
+	httpServer.TLSConfig = tlsConfig
+	return nil
+}
+
+// serveHTTP serves the HTTP server over HTTPS when TLS is configured
+func serveHTTP(httpServer *http.Server) error {
+	if httpServer.TLSConfig != nil {
+		return httpServer.ListenAndServeTLS(cfg.Server.TLS.Cert, cfg.Server.TLS.Key)
+	}
+	return httpServer.ListenAndServe()
+}